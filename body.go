@@ -0,0 +1,116 @@
+package eotel
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithBodyCapture makes Middleware keep up to maxBytes of the request and
+// response bodies and, when the response status is 400 or above, attach
+// them to the request span as http.request.body and http.response.body.
+// Only textual content (text/*, JSON, XML, form data) is captured; multipart,
+// binary and untyped bodies are skipped. Handlers still read the full request
+// body.
+//
+// A captured body goes through the redaction settings as one value under its
+// attribute key: RedactKeys masks it only when it lists that key, and fields
+// inside the body are not matched, so use Config.Redactor to scrub secrets
+// from body text.
+func WithBodyCapture(maxBytes int) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.bodyLimit = maxBytes
+	}
+}
+
+// cappedBuffer keeps the first max bytes written to it and notes whether
+// anything past that was dropped.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// captureRequestBody reads up to limit bytes of r's body into a cappedBuffer
+// and puts them back in front of the rest, so the handler sees the whole
+// body. It returns nil when the body is empty or not textual.
+func captureRequestBody(r *http.Request, limit int) *cappedBuffer {
+	if r.Body == nil || r.Body == http.NoBody || !textualContent(r.Header.Get("Content-Type")) {
+		return nil
+	}
+	prefix, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+	if err != nil || len(prefix) == 0 {
+		return nil
+	}
+	b := &cappedBuffer{max: limit}
+	_, _ = b.Write(prefix)
+	return b
+}
+
+// bodyWriter copies what the handler writes into a cappedBuffer.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *cappedBuffer
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	_, _ = w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	_, _ = w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// recordBody sets the captured body, redacted like a field, on span under
+// key, with key.truncated when it was cut at the limit.
+func recordBody(span trace.Span, key string, b *cappedBuffer) {
+	if b == nil || b.buf.Len() == 0 {
+		return
+	}
+	span.SetAttributes(attributeOf(key, redact(key, b.buf.String())))
+	if b.truncated {
+		span.SetAttributes(attribute.Bool(key+".truncated", true))
+	}
+}
+
+// textualContent reports whether a body of the given Content-Type is worth
+// capturing as text. An unset type is not, since nothing says the body is
+// not binary.
+func textualContent(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mt, "text/"),
+		strings.HasSuffix(mt, "+json"),
+		strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case "application/json", "application/xml", "application/x-www-form-urlencoded", "application/x-ndjson":
+		return true
+	}
+	return false
+}
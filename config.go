@@ -1,39 +1,464 @@
 package eotel
 
-import "os"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	logFormatJSON    = "json"
+	logFormatConsole = "console"
+)
 
 type Config struct {
-	ServiceName   string
-	JobName       string
-	SentryDSN     string
-	SentryOrg     string
-	LokiURL       string
-	OtelCollector string
-	EnableTracing bool
-	EnableMetrics bool
-	EnableSentry  bool
-	EnableLoki    bool
-	LogLevel      string
+	ServiceName   string `yaml:"service_name"`
+	JobName       string `yaml:"job_name"`
+	SentryDSN     string `yaml:"sentry_dsn"`
+	SentryOrg     string `yaml:"sentry_org"`
+	LokiURL       string `yaml:"loki_url"`
+	OtelCollector string `yaml:"otel_collector"`
+	EnableTracing bool   `yaml:"enable_tracing"`
+	EnableMetrics bool   `yaml:"enable_metrics"`
+	EnableSentry  bool   `yaml:"enable_sentry"`
+	EnableLoki    bool   `yaml:"enable_loki"`
+	LogLevel      string `yaml:"log_level"`
+	Propagators   string `yaml:"propagators"`
+
+	// ServiceVersion and ResourceAttributes are added to the OTEL resource
+	// shared by every span and metric, next to service.name, the detected
+	// host attributes and deployment.environment (from SentryEnvironment).
+	// ResourceAttributes override any of those.
+	ServiceVersion     string            `yaml:"service_version"`
+	ResourceAttributes map[string]string `yaml:"resource_attributes"`
+
+	// LogFormat is "json" (default) or "console" for colored, human-readable
+	// output in development. OutputPaths are zap sinks such as "stdout" or a
+	// file path (default stderr).
+	LogFormat   string   `yaml:"log_format"`
+	OutputPaths []string `yaml:"output_paths"`
+	// TimeFormat sets how log timestamps are written: "rfc3339nano",
+	// "rfc3339", "iso8601", "millis", "nanos", "epoch" or a Go time layout.
+	// UTC converts them to UTC first. Loki pushes always carry unix
+	// nanoseconds, as its API requires.
+	TimeFormat string `yaml:"time_format"`
+	UTC        bool   `yaml:"utc"`
+	// AsyncLogging buffers log output in memory and writes it from a
+	// background goroutine, so logging calls return without waiting on the
+	// sink. Lines keep their order; Flush and Shutdown write out the buffer,
+	// but a crash can lose up to a second of them.
+	AsyncLogging bool `yaml:"async_logging"`
+	// ZapLogger, when set, is the zap logger InitEOTEL writes through instead
+	// of building one, e.g. with custom cores or sinks. LogFormat,
+	// OutputPaths, TimeFormat, UTC and AsyncLogging then do not apply;
+	// LogLevel and SetLevel still filter lines before they reach it.
+	ZapLogger *zap.Logger `yaml:"-"`
+
+	// OtelProtocol is the OTLP transport: "grpc" (default) or "http/protobuf".
+	OtelProtocol string `yaml:"otel_protocol"`
+	// OtelHeaders are sent with every OTLP export, e.g. collector auth tokens.
+	OtelHeaders map[string]string `yaml:"otel_headers"`
+	// OtelInsecure disables TLS to the collector. Without it TLS is used
+	// except for http:// and localhost endpoints. OtelTLSCACert is a PEM file
+	// of CAs to trust instead of the system roots.
+	OtelInsecure  bool   `yaml:"otel_insecure"`
+	OtelTLSCACert string `yaml:"otel_tls_ca_cert"`
+
+	// MetricsExporter is how metrics leave the process: "otlp" (default)
+	// pushes them to OtelCollector, "prometheus" serves them from
+	// MetricsHandler for scraping.
+	MetricsExporter string `yaml:"metrics_exporter"`
+
+	// TraceSampleRatio is the fraction of new traces to record (default 1).
+	// Child spans follow their parent's decision.
+	TraceSampleRatio float64 `yaml:"trace_sample_ratio"`
+	// LogSampleRatio is the fraction of info/debug logs to keep; warn and
+	// above are always kept. Values outside (0, 1) keep everything. Lines in
+	// the same trace are kept or dropped together.
+	LogSampleRatio float64 `yaml:"log_sample_ratio"`
+
+	// SpanBatchTimeout, SpanMaxQueueSize and SpanMaxExportBatchSize tune the
+	// batch span processor: how long spans wait before export, how many may
+	// queue (more are dropped) and how many go in one export. Zero keeps the
+	// SDK defaults (5s, 2048, 512).
+	SpanBatchTimeout       time.Duration `yaml:"span_batch_timeout"`
+	SpanMaxQueueSize       int           `yaml:"span_max_queue_size"`
+	SpanMaxExportBatchSize int           `yaml:"span_max_export_batch_size"`
+
+	FatalFlushTimeout time.Duration `yaml:"fatal_flush_timeout"`
+
+	// SentryEnvironment and SentryRelease tag every Sentry event (environment
+	// defaults to "production"). SentrySampleRate is the fraction of error
+	// events sent; 0 sends all of them.
+	SentryEnvironment string  `yaml:"sentry_environment"`
+	SentryRelease     string  `yaml:"sentry_release"`
+	SentrySampleRate  float64 `yaml:"sentry_sample_rate"`
+
+	// SentryCaptureLevel is the minimum level at which an error attached via
+	// WithError is sent to Sentry (default "error").
+	SentryCaptureLevel string `yaml:"sentry_capture_level"`
+
+	// CaptureStack attaches the stack trace of an error added with WithError
+	// to error and fatal log lines. LoadConfigFromEnv and LoadConfigFromFile
+	// turn it on; a Config built in code must set it.
+	CaptureStack bool `yaml:"capture_stack"`
+
+	LokiBatchSize     int           `yaml:"loki_batch_size"`
+	LokiFlushInterval time.Duration `yaml:"loki_flush_interval"`
+
+	LokiMaxRetries     int           `yaml:"loki_max_retries"`
+	LokiRetryBaseDelay time.Duration `yaml:"loki_retry_base_delay"`
+	// LokiCompression is how push bodies are encoded: "gzip" (the default)
+	// or "none".
+	LokiCompression string `yaml:"loki_compression"`
+
+	// LokiTimeout bounds each push request (default 5s). LokiClient replaces
+	// http.DefaultClient for pushes, e.g. to go through a proxy or use mTLS.
+	LokiTimeout time.Duration `yaml:"loki_timeout"`
+	LokiClient  *http.Client  `yaml:"-"`
+
+	// LokiUsername and LokiPassword enable basic auth (as Grafana Cloud
+	// uses); otherwise LokiToken, if set, is sent as a bearer token.
+	// LokiTenantID is sent as X-Scope-OrgID for multi-tenant Loki.
+	LokiUsername string `yaml:"loki_username"`
+	LokiPassword string `yaml:"loki_password"`
+	LokiToken    string `yaml:"loki_token"`
+	LokiTenantID string `yaml:"loki_tenant_id"`
+
+	// LokiOverflowPolicy decides what logging does when the Loki queue is
+	// full: "drop" the entry (default), "block" until there is room, or
+	// wait up to LokiOverflowTimeout and then drop.
+	LokiOverflowPolicy  string        `yaml:"loki_overflow_policy"`
+	LokiOverflowTimeout time.Duration `yaml:"loki_overflow_timeout"`
+
+	// LokiLabels are static labels (env, region, ...) added to every Loki
+	// stream. They cannot replace the level, job, service, trace_id and
+	// span_id labels eotel sets itself.
+	LokiLabels map[string]string `yaml:"loki_labels"`
+	// LokiStructuredMetadata sends the logger's fields with each line as Loki
+	// structured metadata (needs Loki 2.9+ with it enabled).
+	LokiStructuredMetadata bool `yaml:"loki_structured_metadata"`
+	// LokiMinLevel is the lowest level pushed to Loki, e.g. "warn" to keep
+	// info lines local. Empty pushes every line that LogLevel lets through.
+	LokiMinLevel string `yaml:"loki_min_level"`
+
+	// RecordContextErrors flags log lines written after their context was
+	// cancelled (context.cancelled) or timed out (context.deadline_exceeded,
+	// which also marks the span as failed). LoadConfigFromEnv and
+	// LoadConfigFromFile turn it on; a Config built in code must set it.
+	RecordContextErrors bool `yaml:"record_context_errors"`
+
+	// RedactKeys lists field keys (matched case-insensitively) whose values
+	// are replaced with "***" in log fields and span attributes.
+	RedactKeys []string `yaml:"redact_keys"`
+	// Redactor, when set, is called for every other field and may return a
+	// replacement value and true to redact it.
+	Redactor func(key string, value any) (any, bool) `yaml:"-"`
+
+	// DBSystem is the database StartDBSpan reports in db.system, e.g.
+	// "postgresql". ScrubSQL replaces string and number literals in the
+	// statements it records with "?", so values inlined into SQL do not
+	// reach the tracing backend.
+	DBSystem string `yaml:"db_system"`
+	ScrubSQL bool   `yaml:"scrub_sql"`
+
+	// RequestIDHeader is the header, or gRPC metadata key, the middlewares
+	// read the request ID from and echo it back in. Default "X-Request-Id".
+	RequestIDHeader string `yaml:"request_id_header"`
+
+	// EnablePprof serves the runtime profiles under /debug/pprof/ from
+	// DebugHandler. It is off by default, as profiles expose the program's
+	// internals and collecting them costs CPU.
+	EnablePprof bool `yaml:"enable_pprof"`
+
+	// Exporters receive every logger's sends and captures alongside the
+	// default Loki/Sentry exporter, e.g. an alerting webhook. Loggers
+	// created with WithExporter use only the exporter given there.
+	Exporters []Exporter `yaml:"-"`
+
+	// TestMode sends spans to an in-memory exporter instead of the
+	// collector, even with EnableTracing off, for tests to read back with
+	// FlushAndCollectSpans. Metrics, Loki and Sentry are unaffected.
+	TestMode bool `yaml:"-"`
+
+	// Strict makes InitEOTEL fail when Validate reports a problem instead of
+	// logging it and carrying on.
+	Strict bool `yaml:"strict"`
 }
 
 var globalCfg Config
 
 func LoadConfigFromEnv() Config {
+	return configFromEnv(defaultConfig())
+}
+
+// LoadConfigFromFile reads a Config from a YAML (.yaml, .yml) or JSON (.json)
+// file. Settings missing from the file keep LoadConfigFromEnv's defaults, and
+// environment variables that are set override the file.
+func LoadConfigFromFile(path string) (Config, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".json":
+	default:
+		return Config{}, fmt.Errorf("config file %s: unsupported extension %q", path, ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	// JSON is a subset of YAML, so one decoder covers both formats and
+	// durations such as "2s" are accepted in either.
+	cfg := defaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return configFromEnv(cfg), nil
+}
+
+func defaultConfig() Config {
 	return Config{
-		ServiceName:   getEnv("SERVICE_NAME", "eotel"),
-		JobName:       getEnv("JOB_NAME", "eotel-job"),
-		SentryDSN:     getEnv("SENTRY_DSN", ""),
-		SentryOrg:     getEnv("SENTRY_ORG", ""),
-		LokiURL:       getEnv("LOKI_URL", "http://loki:3100/loki/api/v1/push"),
-		OtelCollector: getEnv("OTEL_COLLECTOR", "otel-collector:4317"),
-		EnableTracing: getEnvBool("ENABLE_TRACING", true),
-		EnableMetrics: getEnvBool("ENABLE_METRICS", true),
-		EnableSentry:  getEnvBool("ENABLE_SENTRY", true),
-		EnableLoki:    getEnvBool("ENABLE_LOKI", true),
-		LogLevel:      getEnv("LOG_LEVEL", "info"),
+		ServiceName:   "eotel",
+		JobName:       "eotel-job",
+		LokiURL:       "http://loki:3100/loki/api/v1/push",
+		OtelCollector: "otel-collector:4317",
+		EnableTracing: true,
+		EnableMetrics: true,
+		EnableSentry:  true,
+		EnableLoki:    true,
+		LogLevel:      "info",
+		Propagators:   "tracecontext,baggage",
+
+		RequestIDHeader: "X-Request-Id",
+
+		LogFormat: logFormatJSON,
+
+		OtelProtocol: otlpProtocolGRPC,
+
+		MetricsExporter: metricsExporterOTLP,
+
+		TraceSampleRatio: 1,
+		LogSampleRatio:   1,
+
+		FatalFlushTimeout: 2 * time.Second,
+
+		SentryEnvironment:  "production",
+		SentrySampleRate:   1,
+		SentryCaptureLevel: "error",
+		CaptureStack:       true,
+
+		RecordContextErrors: true,
+
+		LokiBatchSize:     100,
+		LokiFlushInterval: time.Second,
+
+		LokiMaxRetries:     3,
+		LokiRetryBaseDelay: 200 * time.Millisecond,
+		LokiCompression:    "gzip",
+		LokiTimeout:        5 * time.Second,
+
+		LokiOverflowPolicy:  lokiOverflowDrop,
+		LokiOverflowTimeout: 100 * time.Millisecond,
+	}
+}
+
+// configFromEnv returns base with every setting whose environment variable is
+// set replaced by the variable's value.
+func configFromEnv(base Config) Config {
+	return Config{
+		ServiceName:   getEnv("SERVICE_NAME", base.ServiceName),
+		JobName:       getEnv("JOB_NAME", base.JobName),
+		SentryDSN:     getEnv("SENTRY_DSN", base.SentryDSN),
+		SentryOrg:     getEnv("SENTRY_ORG", base.SentryOrg),
+		LokiURL:       getEnv("LOKI_URL", base.LokiURL),
+		OtelCollector: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", getEnv("OTEL_COLLECTOR", base.OtelCollector)),
+		EnableTracing: getEnvBool("ENABLE_TRACING", base.EnableTracing),
+		EnableMetrics: getEnvBool("ENABLE_METRICS", base.EnableMetrics),
+		EnableSentry:  getEnvBool("ENABLE_SENTRY", base.EnableSentry),
+		EnableLoki:    getEnvBool("ENABLE_LOKI", base.EnableLoki),
+		LogLevel:      getEnv("LOG_LEVEL", base.LogLevel),
+		Propagators:   getEnv("OTEL_PROPAGATORS", base.Propagators),
+
+		ServiceVersion:     getEnv("SERVICE_VERSION", base.ServiceVersion),
+		ResourceAttributes: getEnvHeaders("OTEL_RESOURCE_ATTRIBUTES", base.ResourceAttributes),
+
+		LogFormat:   getEnv("LOG_FORMAT", base.LogFormat),
+		OutputPaths: getEnvList("LOG_OUTPUT_PATHS", base.OutputPaths),
+		TimeFormat:  getEnv("LOG_TIME_FORMAT", base.TimeFormat),
+		UTC:         getEnvBool("LOG_UTC", base.UTC),
+
+		AsyncLogging: getEnvBool("LOG_ASYNC", base.AsyncLogging),
+		ZapLogger:    base.ZapLogger,
+
+		OtelProtocol: getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", base.OtelProtocol),
+		OtelHeaders:  getEnvHeaders("OTEL_EXPORTER_OTLP_HEADERS", base.OtelHeaders),
+
+		OtelInsecure:  getEnvBool("OTEL_EXPORTER_OTLP_INSECURE", base.OtelInsecure),
+		OtelTLSCACert: getEnv("OTEL_EXPORTER_OTLP_CERTIFICATE", base.OtelTLSCACert),
+
+		MetricsExporter: getEnv("OTEL_METRICS_EXPORTER", base.MetricsExporter),
+
+		TraceSampleRatio: getEnvFloat("TRACE_SAMPLE_RATIO", base.TraceSampleRatio),
+		LogSampleRatio:   getEnvFloat("LOG_SAMPLE_RATIO", base.LogSampleRatio),
+
+		SpanBatchTimeout:       getEnvDuration("SPAN_BATCH_TIMEOUT", base.SpanBatchTimeout),
+		SpanMaxQueueSize:       getEnvInt("SPAN_MAX_QUEUE_SIZE", base.SpanMaxQueueSize),
+		SpanMaxExportBatchSize: getEnvInt("SPAN_MAX_EXPORT_BATCH_SIZE", base.SpanMaxExportBatchSize),
+
+		FatalFlushTimeout: getEnvDuration("FATAL_FLUSH_TIMEOUT", base.FatalFlushTimeout),
+
+		SentryEnvironment:  getEnv("ENVIRONMENT", base.SentryEnvironment),
+		SentryRelease:      getEnv("SENTRY_RELEASE", base.SentryRelease),
+		SentrySampleRate:   getEnvFloat("SENTRY_SAMPLE_RATE", base.SentrySampleRate),
+		SentryCaptureLevel: getEnv("SENTRY_CAPTURE_LEVEL", base.SentryCaptureLevel),
+		CaptureStack:       getEnvBool("CAPTURE_STACK", base.CaptureStack),
+
+		LokiBatchSize:     getEnvInt("LOKI_BATCH_SIZE", base.LokiBatchSize),
+		LokiFlushInterval: getEnvDuration("LOKI_FLUSH_INTERVAL", base.LokiFlushInterval),
+
+		LokiMaxRetries:     getEnvInt("LOKI_MAX_RETRIES", base.LokiMaxRetries),
+		LokiRetryBaseDelay: getEnvDuration("LOKI_RETRY_BASE_DELAY", base.LokiRetryBaseDelay),
+		LokiCompression:    getEnv("LOKI_COMPRESSION", base.LokiCompression),
+		LokiTimeout:        getEnvDuration("LOKI_TIMEOUT", base.LokiTimeout),
+		LokiClient:         base.LokiClient,
+
+		LokiUsername: getEnv("LOKI_USERNAME", base.LokiUsername),
+		LokiPassword: getEnv("LOKI_PASSWORD", base.LokiPassword),
+		LokiToken:    getEnv("LOKI_TOKEN", base.LokiToken),
+		LokiTenantID: getEnv("LOKI_TENANT_ID", base.LokiTenantID),
+
+		LokiOverflowPolicy:  getEnv("LOKI_OVERFLOW_POLICY", base.LokiOverflowPolicy),
+		LokiOverflowTimeout: getEnvDuration("LOKI_OVERFLOW_TIMEOUT", base.LokiOverflowTimeout),
+
+		LokiLabels:             getEnvHeaders("LOKI_LABELS", base.LokiLabels),
+		LokiStructuredMetadata: getEnvBool("LOKI_STRUCTURED_METADATA", base.LokiStructuredMetadata),
+		LokiMinLevel:           getEnv("LOKI_MIN_LEVEL", base.LokiMinLevel),
+
+		RecordContextErrors: getEnvBool("RECORD_CONTEXT_ERRORS", base.RecordContextErrors),
+
+		RedactKeys: getEnvList("REDACT_KEYS", base.RedactKeys),
+		Redactor:   base.Redactor,
+
+		DBSystem: getEnv("DB_SYSTEM", base.DBSystem),
+		ScrubSQL: getEnvBool("SCRUB_SQL", base.ScrubSQL),
+
+		RequestIDHeader: getEnv("REQUEST_ID_HEADER", base.RequestIDHeader),
+
+		EnablePprof: getEnvBool("ENABLE_PPROF", base.EnablePprof),
+
+		Exporters: base.Exporters,
+		TestMode:  base.TestMode,
+
+		Strict: getEnvBool("STRICT_CONFIG", base.Strict),
 	}
 }
 
+// Validate reports every enabled feature whose required settings are missing
+// or malformed.
+func (c Config) Validate() error {
+	var errs []error
+	exporter, err := metricsExporter(c)
+	if c.EnableMetrics && err != nil {
+		errs = append(errs, fmt.Errorf("metrics: %w", err))
+	}
+	if c.EnableTracing || (c.EnableMetrics && exporter == metricsExporterOTLP) {
+		if c.OtelCollector == "" {
+			errs = append(errs, errors.New("otel: OtelCollector is required"))
+		} else if isEndpointURL(c.OtelCollector) {
+			if u, err := url.Parse(c.OtelCollector); err != nil || u.Host == "" {
+				errs = append(errs, fmt.Errorf("otel: invalid OtelCollector %q, want host:port or a URL", c.OtelCollector))
+			}
+		} else if _, _, err := net.SplitHostPort(c.OtelCollector); err != nil {
+			errs = append(errs, fmt.Errorf("otel: invalid OtelCollector %q, want host:port or a URL", c.OtelCollector))
+		}
+		if _, err := otlpProtocol(c); err != nil {
+			errs = append(errs, fmt.Errorf("otel: %w", err))
+		}
+	}
+	if c.EnableTracing {
+		if c.SpanBatchTimeout < 0 || (c.SpanBatchTimeout > 0 && c.SpanBatchTimeout < time.Millisecond) {
+			errs = append(errs, fmt.Errorf("otel: SpanBatchTimeout %v is below 1ms", c.SpanBatchTimeout))
+		}
+		if c.SpanMaxQueueSize < 0 {
+			errs = append(errs, fmt.Errorf("otel: SpanMaxQueueSize %d is negative", c.SpanMaxQueueSize))
+		}
+		if c.SpanMaxExportBatchSize < 0 {
+			errs = append(errs, fmt.Errorf("otel: SpanMaxExportBatchSize %d is negative", c.SpanMaxExportBatchSize))
+		}
+		queue := c.SpanMaxQueueSize
+		if queue == 0 {
+			queue = sdktrace.DefaultMaxQueueSize
+		}
+		if c.SpanMaxExportBatchSize > queue {
+			errs = append(errs, fmt.Errorf("otel: SpanMaxExportBatchSize %d exceeds the queue size %d", c.SpanMaxExportBatchSize, queue))
+		}
+	}
+	if c.EnableSentry {
+		if c.SentryDSN == "" {
+			errs = append(errs, errors.New("sentry: SentryDSN is required"))
+		} else if _, err := sentry.NewDsn(c.SentryDSN); err != nil {
+			errs = append(errs, fmt.Errorf("sentry: invalid SentryDSN: %w", err))
+		}
+		if c.SentrySampleRate < 0 || c.SentrySampleRate > 1 {
+			errs = append(errs, fmt.Errorf("sentry: SentrySampleRate %v is outside [0, 1]", c.SentrySampleRate))
+		}
+	}
+	if c.EnableLoki {
+		if c.LokiURL == "" {
+			errs = append(errs, errors.New("loki: LokiURL is required"))
+		} else if u, err := url.Parse(c.LokiURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("loki: invalid LokiURL %q, want an http(s) URL", c.LokiURL))
+		}
+		switch c.LokiOverflowPolicy {
+		case "", lokiOverflowDrop, lokiOverflowBlock, lokiOverflowTimeout:
+		default:
+			errs = append(errs, fmt.Errorf("loki: unknown LokiOverflowPolicy %q, want drop, block or timeout", c.LokiOverflowPolicy))
+		}
+		switch c.LokiCompression {
+		case "", "gzip", "none":
+		default:
+			errs = append(errs, fmt.Errorf("loki: unknown LokiCompression %q, want gzip or none", c.LokiCompression))
+		}
+		for _, k := range lokiReservedLabels {
+			if _, ok := c.LokiLabels[k]; ok {
+				errs = append(errs, fmt.Errorf("loki: LokiLabels cannot set reserved label %q", k))
+			}
+		}
+		if c.LokiMinLevel != "" {
+			if _, ok := levels[c.LokiMinLevel]; !ok {
+				errs = append(errs, fmt.Errorf("loki: unknown LokiMinLevel %q", c.LokiMinLevel))
+			}
+		}
+	}
+	if c.LogLevel != "" {
+		if _, ok := levels[c.LogLevel]; !ok {
+			errs = append(errs, fmt.Errorf("unknown log level %q", c.LogLevel))
+		}
+	}
+	switch c.LogFormat {
+	case "", logFormatJSON, logFormatConsole:
+	default:
+		errs = append(errs, fmt.Errorf("unknown log format %q, want %q or %q", c.LogFormat, logFormatJSON, logFormatConsole))
+	}
+	return errors.Join(errs...)
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -51,3 +476,45 @@ func getEnvBool(key string, fallback bool) bool {
 	}
 	return fallback
 }
+
+func getEnvHeaders(key string, fallback map[string]string) map[string]string {
+	if v := os.Getenv(key); v != "" {
+		return parseOTLPHeaders(v)
+	}
+	return fallback
+}
+
+func getEnvList(key string, fallback []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return d
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return f
+	}
+	return fallback
+}
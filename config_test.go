@@ -0,0 +1,124 @@
+package eotel
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"tracing without collector", Config{EnableTracing: true}, "OtelCollector is required"},
+		{"metrics with bad collector", Config{EnableMetrics: true, OtelCollector: "otel-collector"}, `invalid OtelCollector "otel-collector"`},
+		{"unknown otlp protocol", Config{EnableTracing: true, OtelCollector: "otel-collector:4317", OtelProtocol: "http/json"}, `unsupported OTLP protocol "http/json"`},
+		{"unknown metrics exporter", Config{EnableMetrics: true, OtelCollector: "otel-collector:4317", MetricsExporter: "statsd"}, `unsupported metrics exporter "statsd"`},
+		{"sentry without dsn", Config{EnableSentry: true}, "SentryDSN is required"},
+		{"sentry with bad dsn", Config{EnableSentry: true, SentryDSN: "not a dsn"}, "invalid SentryDSN"},
+		{"loki without url", Config{EnableLoki: true}, "LokiURL is required"},
+		{"loki with bad url", Config{EnableLoki: true, LokiURL: "loki:3100"}, `invalid LokiURL "loki:3100"`},
+		{"unknown log level", Config{LogLevel: "verbose"}, `unknown log level "verbose"`},
+		{"loki label overriding a reserved one", Config{EnableLoki: true, LokiURL: "http://loki:3100", LokiLabels: map[string]string{"level": "x"}}, `reserved label "level"`},
+		{"unknown loki overflow policy", Config{EnableLoki: true, LokiURL: "http://loki:3100", LokiOverflowPolicy: "spill"}, `unknown LokiOverflowPolicy "spill"`},
+		{"unknown loki compression", Config{EnableLoki: true, LokiURL: "http://loki:3100", LokiCompression: "snappy"}, `unknown LokiCompression "snappy"`},
+		{"unknown loki min level", Config{EnableLoki: true, LokiURL: "http://loki:3100", LokiMinLevel: "loud"}, `unknown LokiMinLevel "loud"`},
+		{"unknown log format", Config{LogFormat: "logfmt"}, `unknown log format "logfmt"`},
+		{"span batch timeout too short", Config{EnableTracing: true, OtelCollector: "otel-collector:4317", SpanBatchTimeout: time.Microsecond}, "SpanBatchTimeout 1µs is below 1ms"},
+		{"negative span queue size", Config{EnableTracing: true, OtelCollector: "otel-collector:4317", SpanMaxQueueSize: -1}, "SpanMaxQueueSize -1 is negative"},
+		{"span export batch above queue", Config{EnableTracing: true, OtelCollector: "otel-collector:4317", SpanMaxQueueSize: 100, SpanMaxExportBatchSize: 200}, "SpanMaxExportBatchSize 200 exceeds the queue size 100"},
+		{"sentry sample rate out of range", Config{EnableSentry: true, SentryDSN: "https://public@sentry.example.com/1", SentrySampleRate: 1.5}, "SentrySampleRate 1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestValidateConfigAcceptsValidConfig(t *testing.T) {
+	cfg := Config{
+		EnableTracing: true,
+		EnableMetrics: true,
+		OtelCollector: "otel-collector:4317",
+		EnableSentry:  true,
+		SentryDSN:     "https://key@sentry.io/123456",
+		EnableLoki:    true,
+		LokiURL:       "http://loki:3100/loki/api/v1/push",
+		LogLevel:      "debug",
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.NoError(t, Config{}.Validate())
+	// Prometheus metrics are scraped, so no collector is needed.
+	assert.NoError(t, Config{EnableMetrics: true, MetricsExporter: "prometheus"}.Validate())
+}
+
+func TestInitEOTELStrictRejectsInvalidConfig(t *testing.T) {
+	_, err := InitEOTEL(context.Background(), Config{EnableLoki: true, Strict: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "LokiURL is required")
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFromFileYAML(t *testing.T) {
+	path := writeConfigFile(t, "eotel.yaml", `
+service_name: orders
+job_name: orders-job
+enable_sentry: false
+loki_url: http://loki.internal:3100/loki/api/v1/push
+loki_flush_interval: 5s
+trace_sample_ratio: 0.25
+`)
+	t.Setenv("JOB_NAME", "orders-from-env")
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", cfg.ServiceName)
+	assert.Equal(t, "orders-from-env", cfg.JobName)
+	assert.False(t, cfg.EnableSentry)
+	assert.Equal(t, "http://loki.internal:3100/loki/api/v1/push", cfg.LokiURL)
+	assert.Equal(t, 5*time.Second, cfg.LokiFlushInterval)
+	assert.Equal(t, 0.25, cfg.TraceSampleRatio)
+	// Settings absent from the file keep their defaults.
+	assert.True(t, cfg.EnableTracing)
+	assert.Equal(t, 100, cfg.LokiBatchSize)
+}
+
+func TestLoadConfigFromFileJSON(t *testing.T) {
+	path := writeConfigFile(t, "eotel.json", `{"service_name": "orders", "enable_loki": false, "fatal_flush_timeout": "3s"}`)
+
+	cfg, err := LoadConfigFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", cfg.ServiceName)
+	assert.False(t, cfg.EnableLoki)
+	assert.Equal(t, 3*time.Second, cfg.FatalFlushTimeout)
+}
+
+func TestLoadConfigFromFileErrors(t *testing.T) {
+	_, err := LoadConfigFromFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = LoadConfigFromFile(writeConfigFile(t, "bad.yaml", "service_name: [orders"))
+	assert.ErrorContains(t, err, "parse config")
+
+	_, err = LoadConfigFromFile(writeConfigFile(t, "typo.yaml", "service_nmae: orders"))
+	assert.ErrorContains(t, err, "parse config")
+
+	_, err = LoadConfigFromFile(writeConfigFile(t, "eotel.toml", `service_name = "orders"`))
+	assert.ErrorContains(t, err, "unsupported extension")
+}
@@ -0,0 +1,55 @@
+package eotel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type loggerCtxKey struct{}
+
+var fallbackOnce sync.Once
+
+// Inject returns a copy of ctx carrying logger.
+func Inject(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+// FromContext returns the logger injected into ctx. When there is none it
+// falls back to New(ctx, name), which logs under ctx's span if ctx carries
+// one, and emits a one-time debug message since this usually means context
+// propagation is misconfigured.
+func FromContext(ctx context.Context, name string) Logger {
+	lg, ok := FromContextOK(ctx, name)
+	if !ok {
+		fallbackOnce.Do(func() {
+			zapLogger().Debug("eotel: no logger in context, creating a new one", zap.String("name", name))
+		})
+	}
+	return lg
+}
+
+// FromContextOK is like FromContext but also reports whether a logger was
+// found in ctx. An injected eotel logger is returned as a fresh clone, so
+// fields a caller adds never show up in another caller's logs.
+func FromContextOK(ctx context.Context, name string) (Logger, bool) {
+	if lg, ok := ctx.Value(loggerCtxKey{}).(Logger); ok && lg != nil {
+		if e, ok := lg.(*Eotel); ok {
+			return e.clone(), true
+		}
+		return lg, true
+	}
+	return New(ctx, name), false
+}
+
+// FromGin returns a clone of the logger injected into the Gin request context.
+func FromGin(c *gin.Context, name string) Logger {
+	return FromContext(c.Request.Context(), name)
+}
+
+// InjectToGin stores logger in the Gin request context.
+func InjectToGin(c *gin.Context, logger Logger) {
+	c.Request = c.Request.WithContext(Inject(c.Request.Context(), logger))
+}
@@ -0,0 +1,49 @@
+package eotel
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlLiteral matches single-quoted strings, escaped quotes included, and
+// numbers. A leading $ keeps positional placeholders such as $1 out of it.
+var sqlLiteral = regexp.MustCompile(`'(?:[^']|'')*'|\$?\b\d+(?:\.\d+)?\b`)
+
+// scrubSQL replaces the string and number literals in statement with "?".
+func scrubSQL(statement string) string {
+	return sqlLiteral.ReplaceAllStringFunc(statement, func(lit string) string {
+		if strings.HasPrefix(lit, "$") {
+			return lit
+		}
+		return "?"
+	})
+}
+
+// StartDBSpan starts a client span for a database call and returns its
+// context with a logger bound to it. The span carries db.system (from
+// Config.DBSystem), db.operation and db.statement, scrubbed of literals when
+// Config.ScrubSQL is set. End the logger, or log through it, when the call
+// returns; its duration is recorded then.
+func StartDBSpan(ctx context.Context, operation, statement string) (context.Context, Logger) {
+	if globalCfg.ScrubSQL {
+		statement = scrubSQL(statement)
+	}
+	name := operation
+	if name == "" {
+		name = "db"
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.operation", operation),
+		attribute.String("db.statement", statement),
+	}
+	if globalCfg.DBSystem != "" {
+		attrs = append(attrs, attribute.String("db.system", globalCfg.DBSystem))
+	}
+	l := New(ctx, name).(*Eotel)
+	c := l.child(name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return c.ctx, c
+}
@@ -0,0 +1,40 @@
+package eotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartDBSpanCarriesDBAttributes(t *testing.T) {
+	sr := newSpanRecorder(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", DBSystem: "postgresql", ScrubSQL: true})
+
+	_, logger := StartDBSpan(context.Background(), "SELECT",
+		"SELECT * FROM orders WHERE email = 'bob@example.com' AND total > 10.5 AND id = $1 AND shard2 = 7")
+	logger.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "SELECT", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Contains(t, span.Attributes(), attribute.String("db.system", "postgresql"))
+	assert.Contains(t, span.Attributes(), attribute.String("db.operation", "SELECT"))
+	assert.Contains(t, span.Attributes(), attribute.String("db.statement",
+		"SELECT * FROM orders WHERE email = ? AND total > ? AND id = $1 AND shard2 = ?"))
+	var hasDuration bool
+	for _, kv := range span.Attributes() {
+		hasDuration = hasDuration || kv.Key == "duration_ms"
+	}
+	assert.True(t, hasDuration)
+}
+
+func TestScrubSQL(t *testing.T) {
+	assert.Equal(t, "UPDATE users SET name = ? WHERE id = ?", scrubSQL("UPDATE users SET name = 'O''Brien' WHERE id = 42"))
+	assert.Equal(t, "SELECT col1 FROM t2 WHERE a = $2", scrubSQL("SELECT col1 FROM t2 WHERE a = $2"))
+}
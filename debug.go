@@ -0,0 +1,211 @@
+package eotel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exporterHealth is the outcome of an exporter's latest export, as reported
+// by /healthz. Exporters that have not exported yet are OK.
+type exporterHealth struct {
+	OK         bool      `json:"ok"`
+	Error      string    `json:"error,omitempty"`
+	LastExport time.Time `json:"last_export,omitzero"`
+}
+
+var (
+	healthMu sync.Mutex
+	// health holds the state of the exporters InitEOTEL started, by name:
+	// "traces", "metrics" and "loki".
+	health map[string]exporterHealth
+)
+
+// resetHealth starts tracking the named exporters, forgetting earlier ones.
+func resetHealth(names ...string) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	health = make(map[string]exporterHealth, len(names))
+	for _, name := range names {
+		health[name] = exporterHealth{OK: true}
+	}
+}
+
+// recordExport notes the outcome of an export by the named exporter. It is
+// ignored for exporters resetHealth did not start tracking.
+func recordExport(name string, err error) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	if _, ok := health[name]; !ok {
+		return
+	}
+	h := exporterHealth{OK: err == nil, LastExport: time.Now()}
+	if err != nil {
+		h.Error = err.Error()
+	}
+	health[name] = h
+}
+
+// healthSpanExporter records the outcome of every span export.
+type healthSpanExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e healthSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	recordExport("traces", err)
+	return err
+}
+
+// healthMetricExporter records the outcome of every metric export.
+type healthMetricExporter struct {
+	sdkmetric.Exporter
+}
+
+func (e healthMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	recordExport("metrics", err)
+	return err
+}
+
+// DebugHandler serves eotel's observability endpoints from one mux:
+// /metrics (as MetricsHandler), /healthz and, when Config.EnablePprof is set,
+// the runtime profiles under /debug/pprof/.
+//
+// /healthz answers 200 while the process is up, with a JSON body whose
+// status is "degraded" when an exporter's latest export failed, e.g. the
+// collector or Loki is unreachable, and the state of each exporter.
+func DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+	mux.HandleFunc("/healthz", serveHealth)
+	mux.HandleFunc("/debug/pprof/", servePprof)
+	return mux
+}
+
+func serveHealth(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Status    string                    `json:"status"`
+		Exporters map[string]exporterHealth `json:"exporters"`
+	}{Status: "ok", Exporters: map[string]exporterHealth{}}
+
+	healthMu.Lock()
+	for name, h := range health {
+		body.Exporters[name] = h
+		if !h.OK {
+			body.Status = "degraded"
+		}
+	}
+	healthMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// servePprof serves what net/http/pprof does, except cmdline and symbol.
+// Importing net/http/pprof would register the profiles on
+// http.DefaultServeMux for every program using eotel, so it is avoided.
+func servePprof(w http.ResponseWriter, r *http.Request) {
+	if !globalCfg.EnablePprof {
+		http.NotFound(w, r)
+		return
+	}
+	switch name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/"); name {
+	case "":
+		servePprofIndex(w)
+	case "profile":
+		serveCPUProfile(w, r)
+	case "trace":
+		serveExecutionTrace(w, r)
+	default:
+		serveProfile(w, r, name)
+	}
+}
+
+func servePprofIndex(w http.ResponseWriter) {
+	profiles := pprof.Profiles()
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name() < profiles[j].Name() })
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, p := range profiles {
+		fmt.Fprintf(w, "%s\t%d\n", p.Name(), p.Count())
+	}
+	fmt.Fprintln(w, "profile")
+	fmt.Fprintln(w, "trace")
+}
+
+func serveProfile(w http.ResponseWriter, r *http.Request, name string) {
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.Error(w, fmt.Sprintf("eotel: unknown profile %q", name), http.StatusNotFound)
+		return
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		setAttachment(w, name)
+	}
+	_ = p.WriteTo(w, debug)
+}
+
+func serveCPUProfile(w http.ResponseWriter, r *http.Request) {
+	setAttachment(w, "profile")
+	if err := pprof.StartCPUProfile(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, "eotel: could not start CPU profile: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleepCtx(r.Context(), profileDuration(r, 30*time.Second))
+	pprof.StopCPUProfile()
+}
+
+func serveExecutionTrace(w http.ResponseWriter, r *http.Request) {
+	setAttachment(w, "trace")
+	if err := trace.Start(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, "eotel: could not start trace: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sleepCtx(r.Context(), profileDuration(r, time.Second))
+	trace.Stop()
+}
+
+func setAttachment(w http.ResponseWriter, name string) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+}
+
+// profileDuration returns the ?seconds= of r, or def when it is missing or
+// not a positive number.
+func profileDuration(r *http.Request, def time.Duration) time.Duration {
+	sec, err := strconv.Atoi(r.FormValue("seconds"))
+	if err != nil || sec <= 0 {
+		return def
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// sleepCtx waits for d or until ctx is done, e.g. the client went away, and
+// reports whether the full d passed.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
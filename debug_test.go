@@ -0,0 +1,82 @@
+package eotel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+)
+
+func serveDebug(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+type healthBody struct {
+	Status    string                    `json:"status"`
+	Exporters map[string]exporterHealth `json:"exporters"`
+}
+
+func TestDebugHandlerServesHealthAndMetrics(t *testing.T) {
+	t.Cleanup(func() { otel.SetMeterProvider(metricnoop.NewMeterProvider()) })
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableMetrics: true, MetricsExporter: "prometheus"})
+
+	logger := New(context.Background(), "TestLogger")
+	logger.Info("scraped")
+	logger.End()
+
+	rec := serveDebug(t, "/healthz")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body healthBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "ok", body.Status)
+
+	rec = serveDebug(t, "/metrics")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "log_total{")
+
+	// pprof is off unless enabled.
+	assert.Equal(t, http.StatusNotFound, serveDebug(t, "/debug/pprof/").Code)
+}
+
+func TestHealthzReportsFailingExporter(t *testing.T) {
+	loki := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(loki.Close)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+
+	New(context.Background(), "TestLogger").Info("lost")
+	require.NoError(t, Flush(context.Background()))
+
+	rec := serveDebug(t, "/healthz")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body healthBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "degraded", body.Status)
+	require.Contains(t, body.Exporters, "loki")
+	assert.False(t, body.Exporters["loki"].OK)
+	assert.Contains(t, body.Exporters["loki"].Error, "400")
+}
+
+func TestDebugHandlerServesPprofWhenEnabled(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnablePprof: true})
+
+	rec := serveDebug(t, "/debug/pprof/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = serveDebug(t, "/debug/pprof/goroutine?debug=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine profile:")
+
+	assert.Equal(t, http.StatusNotFound, serveDebug(t, "/debug/pprof/nope").Code)
+}
@@ -0,0 +1,61 @@
+package eotel
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLogger backs the package-level logging functions. InitEOTEL sets it
+// and Shutdown clears it.
+var defaultLogger atomic.Pointer[Logger]
+
+// SetDefault makes logger the one Info, Error, Debug, Warn and their Ctx
+// variants log through, e.g. one carrying the fields every line of the
+// service should have. InitEOTEL replaces it with a plain New logger.
+func SetDefault(logger Logger) {
+	if logger == nil {
+		defaultLogger.Store(nil)
+		return
+	}
+	defaultLogger.Store(&logger)
+}
+
+// Default returns a copy of the logger set with SetDefault or by InitEOTEL,
+// or of a new one when neither has happened yet. An eotel logger is copied
+// without its span and breadcrumb trail, so each line gets a span of its own,
+// unrelated lines never end up as each other's Sentry breadcrumbs, and
+// concurrent callers share nothing they modify.
+func Default() Logger {
+	var lg Logger
+	if p := defaultLogger.Load(); p != nil {
+		lg = *p
+	} else {
+		lg = New(context.Background(), globalCfg.ServiceName)
+	}
+	e, ok := lg.(*Eotel)
+	if !ok {
+		return lg
+	}
+	c := e.clone()
+	c.span, c.ownSpan, c.start, c.ended = nil, false, time.Now(), false
+	c.crumbs = nil
+	if _, ok := c.exporter.(defaultExporter); ok {
+		c.exporter = defaultExporter{}
+	}
+	return c
+}
+
+// Info logs msg through the default logger. Like the other package-level
+// functions it uses the default logger's context, context.Background()
+// unless SetDefault says otherwise; InfoCtx takes one.
+func Info(msg string)  { Default().Info(msg) }
+func Error(msg string) { Default().Error(msg) }
+func Debug(msg string) { Default().Debug(msg) }
+func Warn(msg string)  { Default().Warn(msg) }
+
+// InfoCtx is like Info but logs under the span carried by ctx, if any.
+func InfoCtx(ctx context.Context, msg string)  { Default().InfoCtx(ctx, msg) }
+func ErrorCtx(ctx context.Context, msg string) { Default().ErrorCtx(ctx, msg) }
+func DebugCtx(ctx context.Context, msg string) { Default().DebugCtx(ctx, msg) }
+func WarnCtx(ctx context.Context, msg string)  { Default().WarnCtx(ctx, msg) }
@@ -0,0 +1,92 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageFunctionsLogThroughInitPipeline(t *testing.T) {
+	sr := newSpanRecorder(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	initTestEOTEL(t, Config{ServiceName: "test-service", LogLevel: "debug", OutputPaths: []string{path}})
+
+	Info("package info")
+	Warn("package warn")
+	Debug("package debug")
+	Error("package error")
+	require.NoError(t, zapLogger().Sync())
+
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, msg := range []string{"package info", "package warn", "package debug", "package error"} {
+		assert.Contains(t, string(out), msg)
+	}
+	assert.Contains(t, string(out), `"service":"test-service"`)
+
+	// Each line is recorded under a span of its own.
+	spans := sr.Ended()
+	require.Len(t, spans, 4)
+	assert.NotEqual(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+}
+
+func TestSetDefaultAndCtxVariants(t *testing.T) {
+	sr := newSpanRecorder(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+
+	var (
+		mu    sync.Mutex
+		lines []LogRecord
+	)
+	SetDefault(New(context.Background(), "default", OnLog(func(r LogRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, r)
+	})).WithField("component", "billing"))
+
+	ctx, span := Tracer().Start(context.Background(), "request")
+	InfoCtx(ctx, "under the request")
+	span.End()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Info("concurrent")
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, lines, 11)
+	assert.Equal(t, "under the request", lines[0].Message)
+	assert.Equal(t, span.SpanContext().TraceID().String(), lines[0].TraceID)
+	assert.Equal(t, "billing", lines[0].Fields["component"])
+	assert.Len(t, sr.Ended(), 11)
+}
+
+func TestShutdownClearsDefault(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+	require.NotNil(t, defaultLogger.Load())
+	require.NoError(t, Shutdown(context.Background()))
+	assert.Nil(t, defaultLogger.Load())
+}
+
+func TestDefaultLoggerKeepsNoSharedBreadcrumbs(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+	transport := newSentryTransport(t)
+
+	Info("first unrelated line")
+	Warn("second unrelated line")
+	Default().WithError(errors.New("payment declined")).Error("checkout failed")
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	assert.Empty(t, events[0].Breadcrumbs)
+}
@@ -0,0 +1,75 @@
+// Package eotelecho provides eotel middleware and context helpers for Echo.
+// It is a module of its own so that only Echo users depend on Echo.
+package eotelecho
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nicedev97/eotel"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EchoMiddleware is the Echo counterpart of eotel.Middleware.
+func EchoMiddleware(name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			req := c.Request()
+
+			// Extract incoming trace context and baggage
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			// Start root span, named after the route template rather than the
+			// concrete path to keep span names few
+			ctx, span := eotel.Tracer().Start(ctx, fmt.Sprintf("%s %s", req.Method, c.Path()),
+				trace.WithAttributes(
+					attribute.String("http.route", c.Path()),
+					attribute.String("url.path", req.URL.Path),
+				))
+			defer span.End()
+
+			// Create logger
+			logger := eotel.New(ctx, name).
+				WithField("method", req.Method).
+				WithField("path", req.URL.Path).
+				WithField("ip", c.RealIP()).
+				WithField("ua", req.UserAgent())
+
+			// Tag the request with the caller's ID, or a new one
+			logger = logger.WithRequestID(eotel.ResolveRequestID(span, req.Header.Get, c.Response().Header().Set))
+
+			defer logger.End()
+
+			// Inject logger into context
+			c.SetRequest(req.WithContext(eotel.Inject(ctx, logger)))
+
+			// Recover panic + log + Sentry
+			defer func() {
+				if rec := recover(); rec != nil {
+					logger.WithError(eotel.NewPanicError(rec)).Error("unhandled panic")
+					err = c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": http.StatusText(http.StatusInternalServerError),
+					})
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// FromEcho returns a clone of the logger injected into the request context,
+// or a new logger named name when there is none.
+func FromEcho(c echo.Context, name string) eotel.Logger {
+	return eotel.FromContext(c.Request().Context(), name)
+}
+
+// InjectToEcho stores logger in the request context so FromEcho returns it.
+func InjectToEcho(c echo.Context, logger eotel.Logger) {
+	req := c.Request()
+	c.SetRequest(req.WithContext(eotel.Inject(req.Context(), logger)))
+}
@@ -0,0 +1,108 @@
+package eotelecho
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nicedev97/eotel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestInjectAndFromEchoRoundTrip(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	logger := eotel.New(context.Background(), "injected").WithField("k", "v")
+	InjectToEcho(c, logger)
+
+	assert.Equal(t, logger, FromEcho(c, "handler"))
+}
+
+func TestEchoMiddlewareInjectsLogger(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware("test"))
+
+	var got eotel.Logger
+	e.GET("/users/:id", func(c echo.Context) error {
+		got = FromEcho(c, "handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotNil(t, got)
+}
+
+func TestEchoMiddlewareNamesSpansByRoute(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	e := echo.New()
+	e.Use(EchoMiddleware("test"))
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	for i, s := range spans {
+		assert.Equal(t, "GET /users/:id", s.Name())
+		assert.Contains(t, s.Attributes(), attribute.String("url.path", []string{"/users/1", "/users/2"}[i]))
+	}
+}
+
+func TestEchoMiddlewareRequestID(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware("test"))
+	var got string
+	e.GET("/", func(c echo.Context) error {
+		got = FromEcho(c, "handler").RequestID()
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "req-123", got)
+	assert.Equal(t, "req-123", rec.Header().Get("X-Request-Id"))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotEmpty(t, got)
+	assert.NotEqual(t, "req-123", got)
+	assert.Equal(t, got, rec.Header().Get("X-Request-Id"))
+}
+
+func TestEchoMiddlewareRecoversPanic(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware("test"))
+	e.GET("/explode", func(c echo.Context) error {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/explode", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "Internal Server Error", body["error"])
+}
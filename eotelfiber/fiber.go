@@ -0,0 +1,90 @@
+// Package eotelfiber provides eotel middleware and context helpers for Fiber.
+// It is a module of its own so that only Fiber users depend on Fiber and
+// fasthttp.
+package eotelfiber
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nicedev97/eotel"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FiberMiddleware is the Fiber counterpart of eotel.Middleware. The logger is
+// injected into the Fiber user context.
+func FiberMiddleware(name string) fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		// Extract incoming trace context and baggage from a copy of the
+		// fasthttp headers, whose buffers are reused once the request ends
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaders(c))
+
+		// fasthttp reuses the buffers behind these strings once the request
+		// ends, while the span and logger keep them
+		method := strings.Clone(c.Method())
+		path := strings.Clone(c.Path())
+
+		// Start root span; it is named after the matched route template
+		// once the handlers have run, since c.Route() is still this
+		// middleware's own route here
+		ctx, span := eotel.Tracer().Start(ctx, method,
+			trace.WithAttributes(attribute.String("url.path", path)))
+		defer span.End()
+
+		// Create logger
+		logger := eotel.New(ctx, name).
+			WithField("method", method).
+			WithField("path", path).
+			WithField("ip", strings.Clone(c.IP())).
+			WithField("ua", strings.Clone(c.Get(fiber.HeaderUserAgent)))
+
+		// Tag the request with the caller's ID, or a new one
+		getHeader := func(key string) string { return strings.Clone(c.Get(key)) }
+		logger = logger.WithRequestID(eotel.ResolveRequestID(span, getHeader, c.Set))
+
+		defer logger.End()
+
+		// Inject logger into context
+		c.SetUserContext(eotel.Inject(ctx, logger))
+
+		// Recover panic + log + Sentry
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.WithError(eotel.NewPanicError(rec)).Error("unhandled panic")
+				err = c.SendStatus(fiber.StatusInternalServerError)
+			}
+			route := c.Route().Path
+			span.SetAttributes(attribute.String("http.route", route))
+			span.SetName(fmt.Sprintf("%s %s", method, route))
+		}()
+
+		return c.Next()
+	}
+}
+
+// requestHeaders copies the request headers out of fasthttp's buffers, so
+// the baggage and tracestate extracted from them can outlive the request.
+func requestHeaders(c *fiber.Ctx) propagation.HeaderCarrier {
+	hdr := make(http.Header)
+	c.Request().Header.VisitAll(func(k, v []byte) {
+		hdr.Add(string(k), string(v))
+	})
+	return propagation.HeaderCarrier(hdr)
+}
+
+// FromFiber returns a clone of the logger injected into the Fiber user
+// context, or a new logger named name when there is none.
+func FromFiber(c *fiber.Ctx, name string) eotel.Logger {
+	return eotel.FromContext(c.UserContext(), name)
+}
+
+// InjectToFiber stores logger in the Fiber user context so FromFiber returns
+// it.
+func InjectToFiber(c *fiber.Ctx, logger eotel.Logger) {
+	c.SetUserContext(eotel.Inject(c.UserContext(), logger))
+}
@@ -0,0 +1,187 @@
+package eotelfiber
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nicedev97/eotel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestFiberMiddlewarePropagatesTraceparent(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	app := fiber.New()
+	app.Use(FiberMiddleware("test"))
+	var handlerTraceID string
+	app.Get("/ping", func(c *fiber.Ctx) error {
+		require.NotNil(t, FromFiber(c, "handler"))
+		handlerTraceID = trace.SpanContextFromContext(c.UserContext()).TraceID().String()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerTraceID)
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestFiberMiddlewareBaggageOutlivesRequest(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.Baggage{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	app := fiber.New()
+	app.Use(FiberMiddleware("test"))
+	var loggers []eotel.Logger
+	app.Get("/", func(c *fiber.Ctx) error {
+		loggers = append(loggers, FromFiber(c, "handler"))
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, user := range []string{"alice", "bobby"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("baggage", "user="+user)
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+
+	// The first request's baggage is still its own after the second one
+	// has gone through the same fasthttp buffers.
+	require.Len(t, loggers, 2)
+	assert.Equal(t, "alice", baggage.FromContext(loggers[0].Ctx()).Member("user").Value())
+	assert.Equal(t, "bobby", baggage.FromContext(loggers[1].Ctx()).Member("user").Value())
+}
+
+func TestFiberMiddlewareSpanOutlivesRequest(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	app := fiber.New()
+	app.Use(FiberMiddleware("test"))
+	handler := func(c *fiber.Ctx) error {
+		FromFiber(c, "handler").Info("handled")
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/a", handler)
+	app.Put("/b", handler)
+
+	for _, r := range []struct{ method, path, ua string }{
+		{http.MethodGet, "/a", "agent-one"},
+		{http.MethodPut, "/b", "agent-two"},
+	} {
+		req := httptest.NewRequest(r.method, r.path, nil)
+		req.Header.Set("User-Agent", r.ua)
+		_, err := app.Test(req)
+		require.NoError(t, err)
+	}
+
+	// The first request's span still names and describes it after the
+	// second one has gone through the same fasthttp buffers.
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	first := spans[0]
+	assert.Equal(t, "GET /a", first.Name())
+	assert.Contains(t, first.Attributes(), attribute.String("method", http.MethodGet))
+	assert.Contains(t, first.Attributes(), attribute.String("ua", "agent-one"))
+	assert.Equal(t, "PUT /b", spans[1].Name())
+}
+
+func TestFiberMiddlewareNamesSpansByRoute(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	app := fiber.New()
+	app.Use(FiberMiddleware("test"))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		_, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+	}
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	for i, s := range spans {
+		assert.Equal(t, "GET /users/:id", s.Name())
+		assert.Contains(t, s.Attributes(), attribute.String("http.route", "/users/:id"))
+		assert.Contains(t, s.Attributes(), attribute.String("url.path", []string{"/users/1", "/users/2"}[i]))
+	}
+}
+
+func TestFiberMiddlewareRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(FiberMiddleware("test"))
+	var got string
+	app.Get("/", func(c *fiber.Ctx) error {
+		got = FromFiber(c, "handler").RequestID()
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", got)
+	assert.Equal(t, "req-123", resp.Header.Get("X-Request-Id"))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.NotEmpty(t, got)
+	assert.NotEqual(t, "req-123", got)
+	assert.Equal(t, got, resp.Header.Get("X-Request-Id"))
+}
+
+func TestFiberMiddlewareRecoversPanic(t *testing.T) {
+	app := fiber.New()
+	app.Use(FiberMiddleware("test"))
+	app.Get("/explode", func(c *fiber.Ctx) error {
+		panic("boom")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/explode", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
+
+func TestInjectAndFromFiberRoundTrip(t *testing.T) {
+	app := fiber.New()
+	var injected, got eotel.Logger
+	app.Get("/", func(c *fiber.Ctx) error {
+		injected = eotel.New(c.UserContext(), "injected")
+		InjectToFiber(c, injected)
+		got = FromFiber(c, "handler")
+		return nil
+	})
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, injected, got)
+}
@@ -0,0 +1,172 @@
+// Package eoteltest records what code logs, captures and traces through eotel
+// so tests can assert on it without reaching into the logger's internals.
+package eoteltest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nicedev97/eotel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CapturedError is an error the logger sent to the exporter, as Sentry would
+// receive it.
+type CapturedError struct {
+	Err    error
+	Tags   map[string]string
+	Extras map[string]any
+}
+
+// CapturedMessage is a message sent with Logger.CaptureMessage.
+type CapturedMessage struct {
+	Level   string
+	Message string
+	Tags    map[string]string
+}
+
+// Recorder is an eotel.Exporter that keeps everything in memory, together
+// with a tracer provider whose spans it records. Loggers made with New log,
+// capture and trace into it; it is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	logs     []eotel.LogRecord
+	errors   []CapturedError
+	messages []CapturedMessage
+
+	spans    *tracetest.SpanRecorder
+	provider *sdktrace.TracerProvider
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	spans := tracetest.NewSpanRecorder()
+	return &Recorder{
+		spans:    spans,
+		provider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+	}
+}
+
+// New returns a logger that records into r. Lines below the configured log
+// level, or dropped by log sampling, are not recorded, as they are not
+// written either.
+func New(r *Recorder, opts ...eotel.Option) eotel.Logger {
+	return NewContext(context.Background(), r, opts...)
+}
+
+// NewContext is like New but binds the logger to ctx.
+func NewContext(ctx context.Context, r *Recorder, opts ...eotel.Option) eotel.Logger {
+	opts = append([]eotel.Option{
+		eotel.WithExporter(r),
+		eotel.WithTracerProvider(r.provider),
+		eotel.OnLog(r.record),
+	}, opts...)
+	return eotel.New(ctx, "eoteltest", opts...)
+}
+
+func (r *Recorder) record(rec eotel.LogRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, rec)
+}
+
+// Send does nothing: loggers made with New record every line, whether or
+// not Loki is enabled, through an OnLog hook instead.
+func (r *Recorder) Send(level string, msg string, traceID string, spanID string) {}
+
+func (r *Recorder) CaptureError(err error, tags map[string]string, extras map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, CapturedError{Err: err, Tags: tags, Extras: extras})
+}
+
+func (r *Recorder) CaptureMessage(level, msg string, tags map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, CapturedMessage{Level: level, Message: msg, Tags: tags})
+}
+
+// Logs returns the recorded lines, oldest first.
+func (r *Recorder) Logs() []eotel.LogRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]eotel.LogRecord(nil), r.logs...)
+}
+
+// LastLog returns the most recent line, and false when nothing was logged.
+func (r *Recorder) LastLog() (eotel.LogRecord, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.logs) == 0 {
+		return eotel.LogRecord{}, false
+	}
+	return r.logs[len(r.logs)-1], true
+}
+
+// Errors returns the captured errors, oldest first.
+func (r *Recorder) Errors() []CapturedError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CapturedError(nil), r.errors...)
+}
+
+// Messages returns the captured messages, oldest first.
+func (r *Recorder) Messages() []CapturedMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CapturedMessage(nil), r.messages...)
+}
+
+// Spans returns the spans ended so far, in the order they ended.
+func (r *Recorder) Spans() []sdktrace.ReadOnlySpan {
+	return r.spans.Ended()
+}
+
+// TracerProvider returns the provider whose spans r records, for code that
+// starts spans itself.
+func (r *Recorder) TracerProvider() trace.TracerProvider {
+	return r.provider
+}
+
+// Reset forgets everything recorded so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs, r.errors, r.messages = nil, nil, nil
+	r.spans.Reset()
+}
+
+// AssertLogged reports a test error unless a line at level whose message
+// contains msgSubstr was recorded, and returns whether one was.
+func (r *Recorder) AssertLogged(t testing.TB, level, msgSubstr string) bool {
+	t.Helper()
+	if r.logged(level, msgSubstr) {
+		return true
+	}
+	t.Errorf("eoteltest: no %s line containing %q among %d recorded", level, msgSubstr, len(r.Logs()))
+	return false
+}
+
+// AssertNotLogged reports a test error if a line at level whose message
+// contains msgSubstr was recorded, and returns whether none was.
+func (r *Recorder) AssertNotLogged(t testing.TB, level, msgSubstr string) bool {
+	t.Helper()
+	if !r.logged(level, msgSubstr) {
+		return true
+	}
+	t.Errorf("eoteltest: unexpected %s line containing %q", level, msgSubstr)
+	return false
+}
+
+func (r *Recorder) logged(level, msgSubstr string) bool {
+	for _, rec := range r.Logs() {
+		if rec.Level == level && strings.Contains(rec.Message, msgSubstr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package eoteltest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nicedev97/eotel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// checkout stands in for application code under test.
+func checkout(logger eotel.Logger, orderID int) {
+	logger.Info(fmt.Sprintf("checking out order %d", orderID))
+}
+
+func TestRecorderCollectsLogsErrorsAndSpans(t *testing.T) {
+	rec := NewRecorder()
+	logger := New(rec)
+
+	checkout(logger, 7)
+	logger.WithField("user_id", 7).WithError(errors.New("card declined")).Error("payment failed")
+	logger.CaptureMessage("warn", "retrying payment")
+
+	logs := rec.Logs()
+	require.Len(t, logs, 2)
+	assert.Equal(t, "checking out order 7", logs[0].Message)
+	last, ok := rec.LastLog()
+	require.True(t, ok)
+	assert.Equal(t, "error", last.Level)
+	assert.Equal(t, int64(7), last.Fields["user_id"])
+
+	errs := rec.Errors()
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0].Err, "card declined")
+	assert.Equal(t, int64(7), errs[0].Extras["user_id"])
+	require.Len(t, rec.Messages(), 1)
+	assert.Equal(t, "retrying payment", rec.Messages()[0].Message)
+
+	// The logger's span ended with its first line.
+	spans := rec.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "eoteltest", spans[0].Name())
+
+	rec.AssertLogged(t, "info", "order 7")
+	rec.AssertLogged(t, "error", "payment")
+	rec.AssertNotLogged(t, "error", "order 7")
+}
+
+func TestRecorderTracesChildSpans(t *testing.T) {
+	rec := NewRecorder()
+	child := New(rec).Child("charge")
+	child.WithError(errors.New("card declined")).Error("charge failed")
+
+	spans := rec.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "charge", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	last, _ := rec.LastLog()
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), last.TraceID)
+}
+
+func TestReset(t *testing.T) {
+	rec := NewRecorder()
+	New(rec).WithError(errors.New("boom")).Error("failed")
+	rec.Reset()
+
+	assert.Empty(t, rec.Logs())
+	assert.Empty(t, rec.Errors())
+	assert.Empty(t, rec.Spans())
+	_, ok := rec.LastLog()
+	assert.False(t, ok)
+}
+
+// fakeT records failures instead of failing the test using it.
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertionsReportMismatches(t *testing.T) {
+	rec := NewRecorder()
+	New(rec).Info("hello")
+
+	ft := &fakeT{}
+	assert.False(t, rec.AssertLogged(ft, "error", "hello"))
+	assert.False(t, rec.AssertNotLogged(ft, "info", "hell"))
+	assert.True(t, rec.AssertLogged(ft, "info", "hell"))
+	assert.Len(t, ft.failures, 2)
+}
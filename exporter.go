@@ -0,0 +1,79 @@
+package eotel
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// multiExporter fans every call out to each of its exporters in order.
+type multiExporter []Exporter
+
+// MultiExporter returns an Exporter that forwards each Send, CaptureError and
+// CaptureMessage to every one of exporters, e.g. to report errors to Sentry
+// and to a webhook at once. Exporters that implement EntryExporter receive
+// the full Loki entry. Use it with WithExporter, or list extra exporters in
+// Config.Exporters to add them to every logger.
+func MultiExporter(exporters ...Exporter) Exporter {
+	return multiExporter(append([]Exporter(nil), exporters...))
+}
+
+func (m multiExporter) Send(level string, msg string, traceID string, spanID string) {
+	for _, e := range m {
+		e.Send(level, msg, traceID, spanID)
+	}
+}
+
+func (m multiExporter) SendEntry(entry LokiEntry) {
+	for _, e := range m {
+		if ee, ok := e.(EntryExporter); ok {
+			ee.SendEntry(entry)
+		} else {
+			e.Send(entry.Labels["level"], entry.Message, entry.Labels["trace_id"], entry.Labels["span_id"])
+		}
+	}
+}
+
+func (m multiExporter) CaptureError(err error, tags map[string]string, extras map[string]any) {
+	for _, e := range m {
+		e.CaptureError(err, tags, extras)
+	}
+}
+
+func (m multiExporter) CaptureMessage(level, msg string, tags map[string]string) {
+	for _, e := range m {
+		e.CaptureMessage(level, msg, tags)
+	}
+}
+
+// LogRecord is a log line as passed to an OnLog hook.
+type LogRecord struct {
+	Time    time.Time
+	Level   string
+	Message string
+	TraceID string
+	SpanID  string
+	// Fields holds everything logged with the line besides the IDs above:
+	// the logger's fields, baggage, one-off attrs, the error and its stack,
+	// as zap would encode them.
+	Fields map[string]any
+}
+
+// OnLog calls fn with every line the logger (and its children) writes,
+// whether or not Loki is enabled, so custom sinks can see the full record.
+// fn runs synchronously on the logging goroutine and should not block.
+func OnLog(fn func(LogRecord)) Option {
+	return func(l *Eotel) {
+		l.hooks = append(l.hooks, fn)
+	}
+}
+
+// logRecordFields encodes fields the way zap's JSON output shows them.
+func logRecordFields(fields []zap.Field) map[string]any {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
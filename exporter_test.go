@@ -0,0 +1,65 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiExporterFansOut(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true})
+	first, second := &spyExporter{}, &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(MultiExporter(first, second)))
+
+	mockErr := errors.New("mock error")
+	logger.WithError(mockErr).Error("failed")
+	logger.CaptureMessage("warn", "quota almost exhausted")
+
+	for _, spy := range []*spyExporter{first, second} {
+		sent := spy.Sent()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "error", sent[0].level)
+		assert.Equal(t, "failed", sent[0].msg)
+		assert.Equal(t, []error{mockErr}, spy.Captured())
+		require.Len(t, spy.Messages(), 1)
+		assert.Equal(t, "quota almost exhausted", spy.Messages()[0].msg)
+	}
+}
+
+func TestConfigExportersJoinDefault(t *testing.T) {
+	loki := newFakeLoki(t)
+	spy := &spyExporter{}
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL, Exporters: []Exporter{spy}})
+
+	New(context.Background(), "TestLogger").WithLabel("tenant", "acme").Info("hello")
+	require.NoError(t, Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"hello"}, loki.Messages())
+	assert.Equal(t, "acme", loki.Labels()[0]["tenant"])
+	require.Len(t, spy.Sent(), 1)
+	assert.Equal(t, "hello", spy.Sent()[0].msg)
+}
+
+func TestOnLogReceivesFullRecord(t *testing.T) {
+	newSpanRecorder(t)
+	var records []LogRecord
+	logger := New(context.Background(), "TestLogger", OnLog(func(r LogRecord) {
+		records = append(records, r)
+	})).WithField("order_id", 42).WithError(errors.New("card declined"))
+
+	before := time.Now()
+	logger.WarnAttrs("payment failed", map[string]any{"attempt": 2})
+
+	require.Len(t, records, 1)
+	r := records[0]
+	assert.Equal(t, "warn", r.Level)
+	assert.Equal(t, "payment failed", r.Message)
+	assert.NotEmpty(t, r.TraceID)
+	assert.NotEmpty(t, r.SpanID)
+	assert.False(t, r.Time.Before(before))
+	assert.Equal(t, map[string]any{"order_id": int64(42), "error": "card declined", "attempt": int64(2)}, r.Fields)
+}
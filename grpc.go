@@ -0,0 +1,136 @@
+package eotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor is the gRPC counterpart of Middleware for unary
+// calls. Panics in the handler are returned as codes.Internal.
+func UnaryServerInterceptor(name string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		ctx, span, logger := startGRPC(ctx, name, info.FullMethod)
+		defer span.End()
+		defer logger.End()
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.WithError(NewPanicError(rec)).Error("unhandled panic")
+				err = status.Errorf(codes.Internal, "panic: %v", rec)
+			}
+			recordGRPCStatus(ctx, span, info.FullMethod, err)
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the gRPC counterpart of Middleware for streaming
+// calls. The logger is available from the stream's Context.
+func StreamServerInterceptor(name string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx, span, logger := startGRPC(ss.Context(), name, info.FullMethod)
+		defer span.End()
+		defer logger.End()
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				logger.WithError(NewPanicError(rec)).Error("unhandled panic")
+				err = status.Errorf(codes.Internal, "panic: %v", rec)
+			}
+			recordGRPCStatus(ctx, span, info.FullMethod, err)
+		}()
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func startGRPC(ctx context.Context, name, method string) (context.Context, trace.Span, Logger) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	// Extract incoming trace context and baggage
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	// Start root span
+	ctx, span := Tracer().Start(ctx, method, trace.WithSpanKind(trace.SpanKindServer))
+
+	// Create logger
+	logger := New(ctx, name).
+		WithField("grpc.method", method).
+		WithField("ua", firstMetadata(md, "user-agent"))
+	if p, ok := peer.FromContext(ctx); ok {
+		logger = logger.WithField("peer", p.Addr.String())
+	}
+
+	// Tag the call with the caller's ID, or a new one, echoed in the
+	// response header metadata
+	logger = logger.WithRequestID(ResolveRequestID(span,
+		func(key string) string { return firstMetadata(md, key) },
+		func(key, value string) { _ = grpc.SetHeader(ctx, metadata.Pairs(key, value)) },
+	))
+
+	// Inject logger into context
+	return Inject(ctx, logger), span, logger
+}
+
+func recordGRPCStatus(ctx context.Context, span trace.Span, method string, err error) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if code != codes.OK {
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+
+	counter, cerr := otel.Meter(globalCfg.ServiceName).Int64Counter("grpc_server_requests_total")
+	if cerr != nil {
+		return
+	}
+	counter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("grpc.method", method),
+		attribute.String("grpc.code", code.String()),
+	))
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return firstMetadata(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
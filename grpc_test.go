@@ -0,0 +1,133 @@
+package eotel
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	onCheck func(ctx context.Context)
+	onWatch func(ctx context.Context)
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.Service == "panic" {
+		panic("boom")
+	}
+	s.onCheck(ctx)
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	s.onWatch(stream.Context())
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func newHealthClient(t *testing.T, srv *healthServer) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor("test")),
+		grpc.StreamInterceptor(StreamServerInterceptor("test")),
+	)
+	healthpb.RegisterHealthServer(s, srv)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func loggerFromContext(ctx context.Context) *Eotel {
+	l, _ := ctx.Value(loggerCtxKey{}).(*Eotel)
+	return l
+}
+
+func TestUnaryServerInterceptorInjectsLogger(t *testing.T) {
+	sr := newSpanRecorder(t)
+	var got *Eotel
+	client := newHealthClient(t, &healthServer{onCheck: func(ctx context.Context) { got = loggerFromContext(ctx) }})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	require.NotNil(t, got)
+	assert.Equal(t, "grpc.method", got.fields[0].Key)
+	assert.Equal(t, "/grpc.health.v1.Health/Check", got.fields[0].String)
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "/grpc.health.v1.Health/Check", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("rpc.grpc.status_code", int(codes.OK)))
+}
+
+func TestUnaryServerInterceptorRecoversPanic(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+	client := newHealthClient(t, &healthServer{})
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "panic"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	var root bool
+	for _, s := range sr.Ended() {
+		if s.Name() == "/grpc.health.v1.Health/Check" {
+			root = true
+			assert.Contains(t, s.Attributes(), attribute.Int("rpc.grpc.status_code", int(codes.Internal)))
+		}
+	}
+	assert.True(t, root)
+	assert.Equal(t, int64(1), counterValue(t, reader, "grpc_server_requests_total"))
+}
+
+func TestStreamServerInterceptorInjectsLogger(t *testing.T) {
+	var got *Eotel
+	client := newHealthClient(t, &healthServer{onWatch: func(ctx context.Context) { got = loggerFromContext(ctx) }})
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	require.NotNil(t, got)
+	assert.Equal(t, "/grpc.health.v1.Health/Watch", got.fields[0].String)
+	header, err := stream.Header()
+	require.NoError(t, err)
+	assert.Equal(t, []string{got.RequestID()}, header.Get("X-Request-Id"))
+}
+
+func TestUnaryServerInterceptorRequestID(t *testing.T) {
+	var got string
+	client := newHealthClient(t, &healthServer{onCheck: func(ctx context.Context) { got = loggerFromContext(ctx).RequestID() }})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-123")
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", got)
+	assert.Equal(t, []string{"req-123"}, header.Get("X-Request-Id"))
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.NotEmpty(t, got)
+	assert.NotEqual(t, "req-123", got)
+	assert.Equal(t, []string{got}, header.Get("X-Request-Id"))
+}
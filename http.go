@@ -0,0 +1,82 @@
+package eotel
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTPMiddleware is the net/http counterpart of Middleware.
+func HTTPMiddleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Extract incoming trace context and baggage
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			// Start root span; it is named after the matched route once the
+			// handler has run, see routeSpanName
+			ctx, span := Tracer().Start(ctx, r.Method,
+				trace.WithAttributes(attribute.String("url.path", r.URL.Path)))
+			defer span.End()
+
+			// Create logger
+			logger := New(ctx, name).
+				WithField("method", r.Method).
+				WithField("path", r.URL.Path).
+				WithField("ip", clientIP(r)).
+				WithField("ua", r.UserAgent()).
+				WithRequestID(ResolveRequestID(span, r.Header.Get, w.Header().Set))
+
+			defer logger.End()
+
+			// Inject logger into context
+			r = r.WithContext(Inject(ctx, logger))
+
+			// Recover panic + log + Sentry
+			defer func() {
+				if rec := recover(); rec != nil {
+					recoverHTTPPanic(FromContext(r.Context(), "panic"), span, NewPanicError(rec))
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				nameRouteSpan(span, r.Method, r.Pattern)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// nameRouteSpan names span "METHOD route" after the route template of the
+// ServeMux pattern that matched, e.g. "GET /users/{id}" for /users/42, so
+// span names stay few. The pattern's host, if any, is dropped; without a
+// pattern the span keeps the bare method.
+func nameRouteSpan(span trace.Span, method, pattern string) {
+	i := strings.Index(pattern, "/")
+	if i < 0 {
+		return
+	}
+	route := pattern[i:]
+	span.SetAttributes(attribute.String("http.route", route))
+	span.SetName(method + " " + route)
+}
+
+// clientIP mirrors gin's ClientIP: the first X-Forwarded-For entry, then
+// X-Real-Ip, then the connection's remote address.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		ip, _, _ := strings.Cut(fwd, ",")
+		return strings.TrimSpace(ip)
+	}
+	if ip := r.Header.Get("X-Real-Ip"); ip != "" {
+		return ip
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
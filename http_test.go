@@ -0,0 +1,70 @@
+package eotel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestHTTPMiddlewareRecoversPanic(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+	transport := newSentryTransport(t)
+
+	var fromCtx Logger
+	handler := HTTPMiddleware("test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromCtx = FromContext(r.Context(), "handler")
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/explode", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.IsType(t, &Eotel{}, fromCtx)
+	assert.Len(t, fromCtx.(*Eotel).fields, 5)
+	events := transport.Events()
+	require.Len(t, events, 1)
+	require.NotEmpty(t, events[0].Exception)
+	assert.Equal(t, "panic: boom", events[0].Exception[0].Value)
+	require.NotNil(t, events[0].Exception[0].Stacktrace)
+	frames := events[0].Exception[0].Stacktrace.Frames
+	require.NotEmpty(t, frames)
+	// Sentry lists the innermost frame last.
+	assert.Equal(t, "TestHTTPMiddlewareRecoversPanic.func1", frames[len(frames)-1].Function)
+}
+
+func TestHTTPMiddlewareNamesSpansByRoute(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := HTTPMiddleware("test")(mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "GET /users/{id}", spans[0].Name())
+	assert.Equal(t, "GET /users/{id}", spans[1].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "/users/{id}"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("url.path", "/users/1"))
+	assert.Contains(t, spans[1].Attributes(), attribute.String("url.path", "/users/2"))
+	assert.Equal(t, "GET", spans[2].Name())
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", clientIP(r))
+
+	r.Header.Set("X-Real-Ip", "10.0.0.2")
+	assert.Equal(t, "10.0.0.2", clientIP(r))
+
+	r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.3")
+	assert.Equal(t, "203.0.113.7", clientIP(r))
+}
@@ -2,77 +2,445 @@ package eotel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/attribute"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
-	"google.golang.org/grpc"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+var (
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+	baseLogger     *zap.Logger
+	// asyncSink buffers baseLogger's output when AsyncLogging is on.
+	asyncSink *asyncWriter
+	// memExporter holds the spans exported in TestMode.
+	memExporter *tracetest.InMemoryExporter
+
+	initMu      sync.Mutex
+	initialized bool
+)
+
+// zapLogger returns the logger built by InitEOTEL, or zap's global logger when
+// InitEOTEL has not been called.
+func zapLogger() *zap.Logger {
+	if baseLogger != nil {
+		return baseLogger
+	}
+	return zap.L()
+}
+
+// syncZap syncs zl, ignoring the EINVAL and ENOTTY that fsync returns for
+// stdout and stderr when they are a terminal or pipe.
+func syncZap(zl *zap.Logger) error {
+	err := zl.Sync()
+	var errs []error
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = multi.Unwrap()
+	} else if err != nil {
+		errs = []error{err}
+	}
+	var kept []error
+	for _, e := range errs {
+		if !errors.Is(e, syscall.EINVAL) && !errors.Is(e, syscall.ENOTTY) {
+			kept = append(kept, e)
+		}
+	}
+	return errors.Join(kept...)
+}
+
+func newZapLogger(cfg Config) (*zap.Logger, error) {
+	zcfg := zapConfig(cfg)
+	if !cfg.AsyncLogging {
+		return zcfg.Build()
+	}
+	sink, _, err := zap.Open(zcfg.OutputPaths...)
+	if err != nil {
+		return nil, err
+	}
+	errSink, _, err := zap.Open(zcfg.ErrorOutputPaths...)
+	if err != nil {
+		return nil, err
+	}
+	enc := zapcore.NewJSONEncoder(zcfg.EncoderConfig)
+	if zcfg.Encoding == logFormatConsole {
+		enc = zapcore.NewConsoleEncoder(zcfg.EncoderConfig)
+	}
+	// Writes land in a bounded buffer that a background goroutine flushes
+	// every second, or sooner when it fills up; Sync and Shutdown flush it.
+	asyncSink = &asyncWriter{
+		buffered: &zapcore.BufferedWriteSyncer{WS: sink, FlushInterval: time.Second},
+		direct:   sink,
+	}
+	return zap.New(zapcore.NewCore(enc, asyncSink, zcfg.Level), zap.ErrorOutput(errSink), zap.AddCaller()), nil
+}
+
+// asyncWriter writes through a BufferedWriteSyncer until Shutdown stops it,
+// and straight to the sink after that, so loggers still holding baseLogger,
+// e.g. a deferred log or Fatal, do not write into a buffer nothing flushes.
+type asyncWriter struct {
+	mu       sync.RWMutex
+	stopped  bool
+	buffered *zapcore.BufferedWriteSyncer
+	direct   zapcore.WriteSyncer
+}
+
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		return w.direct.Write(p)
+	}
+	return w.buffered.Write(p)
+}
+
+func (w *asyncWriter) Sync() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		return w.direct.Sync()
+	}
+	return w.buffered.Sync()
+}
+
+// Stop flushes the buffer and ends its flush loop; later writes go straight
+// to the sink.
+func (w *asyncWriter) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return nil
+	}
+	w.stopped = true
+	return w.buffered.Stop()
+}
+
+func zapConfig(cfg Config) zap.Config {
+	zcfg := zap.NewProductionConfig()
+	zcfg.Level = atomicLevel
+	zcfg.Sampling = nil
+	// log attaches the stack of the logged error itself (see CaptureStack);
+	// zap's own would only point into eotel.
+	zcfg.DisableStacktrace = true
+	if cfg.LogFormat == logFormatConsole {
+		zcfg.Encoding = logFormatConsole
+		zcfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		zcfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	} else {
+		// log adds its own "level" field, so keep zap from writing a second one.
+		zcfg.EncoderConfig.LevelKey = zapcore.OmitKey
+	}
+	if cfg.TimeFormat != "" {
+		zcfg.EncoderConfig.EncodeTime = timeEncoder(cfg.TimeFormat)
+	}
+	if cfg.UTC {
+		local := zcfg.EncoderConfig.EncodeTime
+		zcfg.EncoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			local(t.UTC(), enc)
+		}
+	}
+	if len(cfg.OutputPaths) > 0 {
+		zcfg.OutputPaths = cfg.OutputPaths
+	}
+	return zcfg
+}
+
+// timeEncoder resolves a TimeFormat: one of zap's named encodings
+// ("rfc3339nano", "rfc3339", "iso8601", "millis", "nanos", "epoch") or a Go
+// time layout.
+func timeEncoder(format string) zapcore.TimeEncoder {
+	switch strings.ToLower(format) {
+	case "rfc3339nano", "rfc3339", "iso8601", "millis", "nanos", "epoch":
+		var enc zapcore.TimeEncoder
+		_ = enc.UnmarshalText([]byte(strings.ToLower(format)))
+		return enc
+	}
+	return zapcore.TimeEncoderOfLayout(format)
+}
+
+func newSampler(cfg Config) sdktrace.Sampler {
+	ratio := cfg.TraceSampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// InitEOTEL configures logging, tracing, metrics, Loki and Sentry from cfg.
+// Calling it again before Shutdown is a no-op that keeps the first
+// configuration; call Shutdown first to re-initialise with a different one.
+// The Loki sender runs until ctx is done.
 func InitEOTEL(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	initMu.Lock()
+	defer initMu.Unlock()
+	if initialized {
+		return Shutdown, nil
+	}
+	if err := setupEOTEL(ctx, cfg); err != nil {
+		// Undo whatever was set up before the failure.
+		_ = shutdown(context.Background())
+		return nil, err
+	}
+	initialized = true
+	return Shutdown, nil
+}
+
+func setupEOTEL(ctx context.Context, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		if cfg.Strict {
+			return fmt.Errorf("config: %w", err)
+		}
+		log.Printf("eotel config: %v", err)
+	}
+
 	globalCfg = cfg
+	atomicLevel.SetLevel(parseLevel(cfg.LogLevel))
 
-	res, err := resource.New(ctx,
-		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
-	)
+	zl := cfg.ZapLogger
+	if zl == nil {
+		var err error
+		if zl, err = newZapLogger(cfg); err != nil {
+			return fmt.Errorf("zap logger: %w", err)
+		}
+	}
+	baseLogger = zl
+
+	prop, err := newPropagator(cfg.Propagators)
 	if err != nil {
-		return nil, fmt.Errorf("resource.New: %w", err)
+		return fmt.Errorf("propagator: %w", err)
 	}
+	otel.SetTextMapPropagator(prop)
 
-	if cfg.EnableTracing {
-		tExp, err := otlptracegrpc.New(ctx,
-			otlptracegrpc.WithInsecure(),
-			otlptracegrpc.WithEndpoint(cfg.OtelCollector),
-			otlptracegrpc.WithDialOption(grpc.WithBlock()),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("trace exporter: %w", err)
+	res, err := newResource(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("resource.New: %w", err)
+	}
+
+	// exporters are the names /healthz reports on.
+	var exporters []string
+	if cfg.EnableTracing || cfg.TestMode {
+		var tExp sdktrace.SpanExporter
+		if cfg.TestMode {
+			memExporter = tracetest.NewInMemoryExporter()
+			tExp = memExporter
+		} else {
+			otlpExp, err := newTraceExporter(ctx, cfg)
+			if err != nil {
+				return fmt.Errorf("trace exporter: %w", err)
+			}
+			tExp = healthSpanExporter{otlpExp}
+			exporters = append(exporters, "traces")
 		}
-		tp := sdktrace.NewTracerProvider(
+		tracerProvider = sdktrace.NewTracerProvider(
 			sdktrace.WithResource(res),
-			sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(tExp)),
+			sdktrace.WithSampler(newSampler(cfg)),
+			sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(tExp, batchSpanOptions(cfg)...)),
 		)
-		otel.SetTracerProvider(tp)
+		otel.SetTracerProvider(tracerProvider)
 	}
 
 	if cfg.EnableMetrics {
-		mExp, err := otlpmetricgrpc.New(ctx,
-			otlpmetricgrpc.WithInsecure(),
-			otlpmetricgrpc.WithEndpoint(cfg.OtelCollector),
-			otlpmetricgrpc.WithDialOption(grpc.WithBlock()),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("metric exporter: %w", err)
+		var reader sdkmetric.Reader
+		if exp, _ := metricsExporter(cfg); exp == metricsExporterPrometheus {
+			reg := prometheus.NewRegistry()
+			if reader, err = newPrometheusReader(reg); err != nil {
+				return fmt.Errorf("metric exporter: %w", err)
+			}
+			promRegistry = reg
+		} else {
+			mExp, err := newMetricExporter(ctx, cfg)
+			if err != nil {
+				return fmt.Errorf("metric exporter: %w", err)
+			}
+			reader = sdkmetric.NewPeriodicReader(healthMetricExporter{mExp})
+			exporters = append(exporters, "metrics")
 		}
-		mp := sdkmetric.NewMeterProvider(
+		meterProvider = sdkmetric.NewMeterProvider(
 			sdkmetric.WithResource(res),
-			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(mExp)),
+			sdkmetric.WithReader(reader),
 		)
-		otel.SetMeterProvider(mp)
+		otel.SetMeterProvider(meterProvider)
+	}
+
+	if cfg.EnableLoki {
+		startLoki(ctx)
+		exporters = append(exporters, "loki")
 	}
+	resetHealth(exporters...)
 
 	if cfg.EnableSentry {
-		err := sentry.Init(sentry.ClientOptions{
-			Dsn:              cfg.SentryDSN,
-			EnableTracing:    cfg.EnableTracing,
-			TracesSampleRate: 1.0,
-			Environment:      "production",
-		})
+		err := sentry.Init(sentryOptions(cfg))
 		if err != nil {
 			log.Printf("init Sentry error: %v", err)
 		}
 	}
 
-	return func(ctx context.Context) error {
-		sentry.Flush(2 * time.Second)
+	SetDefault(New(context.Background(), cfg.ServiceName))
+
+	return nil
+}
+
+// newResource describes the service to the tracer and meter providers.
+// Host detection failing only leaves its attributes out.
+func newResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{semconv.ServiceName(cfg.ServiceName)}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersion(cfg.ServiceVersion))
+	}
+	if cfg.SentryEnvironment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentName(cfg.SentryEnvironment))
+	}
+	for _, k := range sortedKeys(cfg.ResourceAttributes) {
+		attrs = append(attrs, attribute.String(k, cfg.ResourceAttributes[k]))
+	}
+	res, err := resource.New(ctx,
+		resource.WithHost(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attrs...),
+	)
+	if errors.Is(err, resource.ErrPartialResource) {
+		log.Printf("eotel resource: %v", err)
+		err = nil
+	}
+	return res, err
+}
+
+func sentryOptions(cfg Config) sentry.ClientOptions {
+	env := cfg.SentryEnvironment
+	if env == "" {
+		env = "production"
+	}
+	return sentry.ClientOptions{
+		Dsn:              cfg.SentryDSN,
+		EnableTracing:    cfg.EnableTracing,
+		TracesSampleRate: 1.0,
+		Environment:      env,
+		Release:          cfg.SentryRelease,
+		SampleRate:       cfg.SentrySampleRate,
+	}
+}
+
+// Shutdown syncs the zap logger, drains queued Loki entries, shuts down the
+// tracer and meter providers created by InitEOTEL and flushes Sentry. It
+// gives up when ctx is done. Code that exits the process itself (as Fatal
+// does) should call it first so in-flight telemetry is not lost. Afterwards
+// InitEOTEL may be called again.
+func Shutdown(ctx context.Context) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+	initialized = false
+	return shutdown(ctx)
+}
+
+// Flush syncs the zap logger, pushes queued Loki entries and exports pending spans and metrics,
+// leaving everything running, e.g. at a checkpoint before a deploy switches
+// traffic over. It gives up when ctx is done. Shutdown does the same as part
+// of closing down.
+func Flush(ctx context.Context) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+	return flush(ctx)
+}
+
+// batchSpanOptions applies the Span* batch settings that are set.
+func batchSpanOptions(cfg Config) []sdktrace.BatchSpanProcessorOption {
+	var opts []sdktrace.BatchSpanProcessorOption
+	if cfg.SpanBatchTimeout > 0 {
+		opts = append(opts, sdktrace.WithBatchTimeout(cfg.SpanBatchTimeout))
+	}
+	if cfg.SpanMaxQueueSize > 0 {
+		opts = append(opts, sdktrace.WithMaxQueueSize(cfg.SpanMaxQueueSize))
+	}
+	if cfg.SpanMaxExportBatchSize > 0 {
+		opts = append(opts, sdktrace.WithMaxExportBatchSize(cfg.SpanMaxExportBatchSize))
+	}
+	return opts
+}
+
+// FlushAndCollectSpans flushes pending spans and returns every span ended
+// since InitEOTEL, in the order they were exported. It returns nil unless
+// InitEOTEL ran with Config.TestMode.
+func FlushAndCollectSpans() []sdktrace.ReadOnlySpan {
+	initMu.Lock()
+	defer initMu.Unlock()
+	if memExporter == nil {
 		return nil
-	}, nil
+	}
+	// The in-memory exporter cannot block, so there is no ctx to honour.
+	_ = tracerProvider.ForceFlush(context.Background())
+	return memExporter.GetSpans().Snapshots()
+}
+
+func flush(ctx context.Context) error {
+	var errs []error
+	if baseLogger != nil {
+		if err := syncZap(baseLogger); err != nil {
+			errs = append(errs, fmt.Errorf("zap sync: %w", err))
+		}
+	}
+	if err := flushLoki(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("loki flush: %w", err))
+	}
+	if tracerProvider != nil {
+		if err := tracerProvider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("tracer provider: %w", err))
+		}
+	}
+	if meterProvider != nil {
+		if err := meterProvider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("meter provider: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shutdown flushes everything as flush does, then stops what InitEOTEL
+// started.
+func shutdown(ctx context.Context) error {
+	errs := []error{flush(ctx)}
+	if asyncSink != nil {
+		if err := asyncSink.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("zap async writer: %w", err))
+		}
+		asyncSink = nil
+	}
+	if err := stopLoki(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("loki stop: %w", err))
+	}
+	if tracerProvider != nil {
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("tracer provider: %w", err))
+		}
+		tracerProvider = nil
+		memExporter = nil
+	}
+	if meterProvider != nil {
+		if err := meterProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("meter provider: %w", err))
+		}
+		meterProvider = nil
+		promRegistry = nil
+	}
+	resetHealth()
+	SetDefault(nil)
+	// Sentry gets at most 2s, and less when ctx ends sooner.
+	sentryCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	sentry.FlushWithContext(sentryCtx)
+	cancel()
+	return errors.Join(errs...)
 }
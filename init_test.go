@@ -0,0 +1,421 @@
+package eotel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/goleak"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func initTestEOTEL(t testing.TB, cfg Config) {
+	initTestEOTELContext(t, context.Background(), cfg)
+}
+
+func initTestEOTELContext(t testing.TB, ctx context.Context, cfg Config) {
+	prevCfg, prevLogger, prevLevel := globalCfg, baseLogger, atomicLevel.Level()
+	prevProp := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		_ = Shutdown(context.Background())
+		globalCfg, baseLogger = prevCfg, prevLogger
+		atomicLevel.SetLevel(prevLevel)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	_, err := InitEOTEL(ctx, cfg)
+	require.NoError(t, err)
+}
+
+func TestShutdownDrainsLokiQueue(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+
+	logger := New(context.Background(), "TestLogger")
+	logger.Info("first")
+	logger.Info("second")
+
+	require.NoError(t, Shutdown(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, loki.Messages())
+}
+
+func TestFlushDeliversWithoutShuttingDown(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL, LokiFlushInterval: time.Hour})
+
+	logger := New(context.Background(), "TestLogger")
+	logger.Info("before checkpoint")
+	require.NoError(t, Flush(context.Background()))
+	assert.Equal(t, []string{"before checkpoint"}, loki.Messages())
+
+	logger.Info("after checkpoint")
+	require.NoError(t, Flush(context.Background()))
+	assert.Equal(t, []string{"before checkpoint", "after checkpoint"}, loki.Messages())
+	assert.NotNil(t, currentLoki())
+}
+
+type syncSpy struct {
+	syncs int
+	err   error
+}
+
+func (s *syncSpy) Write(p []byte) (int, error) { return len(p), nil }
+
+func (s *syncSpy) Sync() error {
+	s.syncs++
+	return s.err
+}
+
+func TestSyncIgnoresConsoleErrors(t *testing.T) {
+	spy := &syncSpy{}
+	l := New(context.Background(), "TestLogger").(*Eotel)
+	l.logger = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), spy, zapcore.InfoLevel))
+
+	require.NoError(t, l.Sync())
+	assert.Equal(t, 1, spy.syncs)
+
+	spy.err = &os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.EINVAL}
+	assert.NoError(t, l.Sync())
+
+	spy.err = &os.PathError{Op: "sync", Path: "app.log", Err: syscall.EIO}
+	assert.ErrorIs(t, l.Sync(), syscall.EIO)
+	assert.Equal(t, 3, spy.syncs)
+}
+
+func TestFlushSyncsZapLogger(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+	spy := &syncSpy{}
+	baseLogger = zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), spy, zapcore.InfoLevel))
+
+	require.NoError(t, Flush(context.Background()))
+	assert.Equal(t, 1, spy.syncs)
+}
+
+func TestShutdownRespectsContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: srv.URL})
+
+	New(context.Background(), "TestLogger").Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, Shutdown(context.Background()))
+}
+
+func TestSamplerHonorsRatioAndParent(t *testing.T) {
+	sampler := newSampler(Config{TraceSampleRatio: 0.5})
+	low := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	high := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	decide := func(ctx context.Context, id trace.TraceID) sdktrace.SamplingDecision {
+		return sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, TraceID: id, Name: "op"}).Decision
+	}
+	assert.Equal(t, sdktrace.RecordAndSample, decide(context.Background(), low))
+	assert.Equal(t, sdktrace.Drop, decide(context.Background(), high))
+
+	parent := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    high,
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	assert.Equal(t, sdktrace.RecordAndSample, decide(parent, high))
+}
+
+func TestSamplerDefaultsToAlwaysSample(t *testing.T) {
+	sampler := newSampler(Config{})
+	high := trace.TraceID{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	res := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: high, Name: "op"})
+	assert.Equal(t, sdktrace.RecordAndSample, res.Decision)
+}
+
+func TestInitEOTELTwiceIsNoop(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "first", EnableLoki: true, LokiURL: loki.URL})
+	sender := currentLoki()
+
+	shutdown, err := InitEOTEL(context.Background(), Config{ServiceName: "second", EnableLoki: true, LokiURL: loki.URL})
+	require.NoError(t, err)
+	require.NotNil(t, shutdown)
+	assert.Equal(t, "first", globalCfg.ServiceName)
+	assert.Same(t, sender, currentLoki())
+
+	require.NoError(t, Shutdown(context.Background()))
+	initTestEOTEL(t, Config{ServiceName: "second", EnableLoki: true, LokiURL: loki.URL})
+	assert.Equal(t, "second", globalCfg.ServiceName)
+	assert.NotSame(t, sender, currentLoki())
+}
+
+func TestInitShutdownLoopDoesNotLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+	loki := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer loki.Close()
+
+	cfg := Config{
+		ServiceName:   "test-service",
+		EnableTracing: true,
+		EnableMetrics: true,
+		OtelCollector: collector.URL,
+		OtelProtocol:  "http/protobuf",
+		EnableLoki:    true,
+		LokiURL:       loki.URL,
+	}
+	prevCfg, prevLogger := globalCfg, baseLogger
+	prevTP, prevMP, prevProp := otel.GetTracerProvider(), otel.GetMeterProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		globalCfg, baseLogger = prevCfg, prevLogger
+		otel.SetTextMapPropagator(prevProp)
+		otel.SetTracerProvider(prevTP)
+		otel.SetMeterProvider(prevMP)
+	}()
+
+	for range 5 {
+		_, err := InitEOTEL(context.Background(), cfg)
+		require.NoError(t, err)
+		New(context.Background(), "TestLogger").Info("tick")
+		require.NoError(t, Shutdown(context.Background()))
+	}
+	http.DefaultClient.CloseIdleConnections()
+}
+
+func TestResourceCarriesVersionAndAttributes(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+	prevTP := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prevTP) })
+	initTestEOTEL(t, Config{
+		ServiceName:        "test-service",
+		ServiceVersion:     "1.4.2",
+		SentryEnvironment:  "staging",
+		ResourceAttributes: map[string]string{"team": "payments", "host.name": "override"},
+		EnableTracing:      true,
+		OtelCollector:      collector.URL,
+		OtelProtocol:       "http/protobuf",
+	})
+
+	_, span := otel.Tracer("test").Start(context.Background(), "op")
+	span.End()
+	ro, ok := span.(sdktrace.ReadOnlySpan)
+	require.True(t, ok)
+
+	attrs := ro.Resource().Set()
+	for key, want := range map[string]string{
+		"service.name":                "test-service",
+		"service.version":             "1.4.2",
+		"deployment.environment.name": "staging",
+		"team":                        "payments",
+		"host.name":                   "override",
+	} {
+		got, ok := attrs.Value(attribute.Key(key))
+		if assert.True(t, ok, key) {
+			assert.Equal(t, want, got.AsString(), key)
+		}
+	}
+}
+
+func TestLogFormatSelectsEncoder(t *testing.T) {
+	assert.Equal(t, "json", zapConfig(Config{}).Encoding)
+	assert.Equal(t, "json", zapConfig(Config{LogFormat: "json"}).Encoding)
+	assert.Equal(t, "console", zapConfig(Config{LogFormat: "console"}).Encoding)
+
+	for _, format := range []string{"json", "console"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			initTestEOTEL(t, Config{ServiceName: "test-service", LogFormat: format, OutputPaths: []string{path}})
+
+			New(context.Background(), "TestLogger").Info("written to file")
+			require.NoError(t, zapLogger().Sync())
+
+			out, err := os.ReadFile(path)
+			require.NoError(t, err)
+			line := strings.TrimSpace(string(out))
+			require.Contains(t, line, "written to file")
+			if format == "json" {
+				assert.True(t, json.Valid([]byte(line)), line)
+			} else {
+				assert.False(t, json.Valid([]byte(line)), line)
+				assert.Contains(t, line, "INFO")
+			}
+		})
+	}
+}
+
+func TestLogTimestampFormatAndUTC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	initTestEOTEL(t, Config{ServiceName: "test-service", TimeFormat: "RFC3339Nano", UTC: true, OutputPaths: []string{path}})
+
+	New(context.Background(), "TestLogger").Info("timestamped")
+	require.NoError(t, zapLogger().Sync())
+
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(out, &entry))
+	ts, ok := entry["ts"].(string)
+	require.True(t, ok, "ts should be a string, got %v", entry["ts"])
+	parsed, err := time.Parse(time.RFC3339Nano, ts)
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(ts, "Z"), ts)
+	assert.WithinDuration(t, time.Now(), parsed, time.Minute)
+}
+
+func TestAsyncLoggingKeepsEveryLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	initTestEOTEL(t, Config{ServiceName: "test-service", AsyncLogging: true, OutputPaths: []string{path}})
+
+	logger := New(context.Background(), "TestLogger")
+	for i := range 100 {
+		logger.Infof("line %d", i)
+	}
+	require.NoError(t, Flush(context.Background()))
+	assert.Len(t, readLogMessages(t, path), 100)
+
+	logger.Info("after flush")
+	require.NoError(t, Shutdown(context.Background()))
+	msgs := readLogMessages(t, path)
+	require.Len(t, msgs, 101)
+	for i := range 100 {
+		assert.Equal(t, fmt.Sprintf("line %d", i), msgs[i])
+	}
+	assert.Equal(t, "after flush", msgs[100])
+}
+
+func TestAsyncLoggingWritesAfterShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	initTestEOTEL(t, Config{ServiceName: "test-service", AsyncLogging: true, OutputPaths: []string{path}})
+
+	logger := New(context.Background(), "TestLogger")
+	logger.Info("before shutdown")
+	require.NoError(t, Shutdown(context.Background()))
+
+	logger.Info("after shutdown")
+	New(context.Background(), "LateLogger").Info("late logger")
+	assert.Equal(t, []string{"before shutdown", "after shutdown", "late logger"}, readLogMessages(t, path))
+}
+
+func readLogMessages(t *testing.T, path string) []string {
+	t.Helper()
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var entry struct {
+			Msg string `json:"msg"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		msgs = append(msgs, entry.Msg)
+	}
+	return msgs
+}
+
+func BenchmarkLogSync(b *testing.B)  { benchmarkLog(b, false) }
+func BenchmarkLogAsync(b *testing.B) { benchmarkLog(b, true) }
+
+func benchmarkLog(b *testing.B, async bool) {
+	path := filepath.Join(b.TempDir(), "app.log")
+	initTestEOTEL(b, Config{ServiceName: "bench-service", AsyncLogging: async, OutputPaths: []string{path}})
+	logger := New(context.Background(), "BenchLogger").WithField("order_id", 42)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		logger.Info("order shipped")
+	}
+}
+
+func TestTimeEncoderAcceptsLayouts(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	when := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	require.NoError(t, enc.AddArray("t", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		timeEncoder("2006-01-02 15:04")(when, arr)
+		timeEncoder("rfc3339")(when, arr)
+		return nil
+	})))
+	assert.Equal(t, []any{"2024-03-01 12:30", "2024-03-01T12:30:00Z"}, enc.Fields["t"])
+}
+
+func TestTestModeCollectsSpanHierarchy(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", TestMode: true})
+
+	root := New(context.Background(), "request").Child("handle")
+	lookup := root.Child("lookup")
+	lookup.Info("looked up")
+	root.Info("handled")
+
+	spans := FlushAndCollectSpans()
+	require.Len(t, spans, 2)
+	child, parent := spans[0], spans[1]
+	assert.Equal(t, "lookup", child.Name())
+	assert.Equal(t, "handle", parent.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), child.Parent().SpanID())
+	assert.Equal(t, parent.SpanContext().TraceID(), child.SpanContext().TraceID())
+	assert.False(t, parent.Parent().IsValid())
+	assert.Contains(t, child.Attributes(), attribute.String("log.message", "looked up"))
+
+	require.NoError(t, Shutdown(context.Background()))
+	assert.Nil(t, FlushAndCollectSpans())
+}
+
+func TestBatchSpanOptions(t *testing.T) {
+	var o sdktrace.BatchSpanProcessorOptions
+	for _, opt := range batchSpanOptions(Config{
+		SpanBatchTimeout:       250 * time.Millisecond,
+		SpanMaxQueueSize:       8192,
+		SpanMaxExportBatchSize: 1024,
+	}) {
+		opt(&o)
+	}
+	assert.Equal(t, 250*time.Millisecond, o.BatchTimeout)
+	assert.Equal(t, 8192, o.MaxQueueSize)
+	assert.Equal(t, 1024, o.MaxExportBatchSize)
+
+	assert.Empty(t, batchSpanOptions(Config{}), "zero values keep the SDK defaults")
+}
+
+func TestInitUsesConfiguredZapLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	initTestEOTEL(t, Config{ServiceName: "test-service", ZapLogger: zap.New(core)})
+
+	New(context.Background(), "TestLogger").Info("into the observer")
+	Warn("package-level too")
+	// LogLevel still applies in front of the injected logger.
+	New(context.Background(), "TestLogger").Debug("filtered")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "into the observer", entries[0].Message)
+	assert.Equal(t, "test-service", entries[0].ContextMap()["service"])
+	assert.Equal(t, "package-level too", entries[1].Message)
+}
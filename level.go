@@ -0,0 +1,67 @@
+package eotel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var levels = map[string]zapcore.Level{
+	"debug": zapcore.DebugLevel,
+	"info":  zapcore.InfoLevel,
+	"warn":  zapcore.WarnLevel,
+	"error": zapcore.ErrorLevel,
+	"fatal": zapcore.FatalLevel,
+}
+
+// atomicLevel is shared by the zap logger built in InitEOTEL and by log, so
+// SetLevel takes effect for every existing logger at once.
+var atomicLevel = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+// parseLevel maps the LogLevel strings accepted in Config to zap levels,
+// falling back to info for anything it does not recognise.
+func parseLevel(level string) zapcore.Level {
+	if lvl, ok := levels[level]; ok {
+		return lvl
+	}
+	return zapcore.InfoLevel
+}
+
+func levelEnabled(level string) bool {
+	return atomicLevel.Enabled(parseLevel(level))
+}
+
+// sampleLog reports whether a log at level survives LogSampleRatio. Only
+// info and debug logs are ever sampled out. Lines logged within a trace share
+// one decision derived from the trace ID, so a trace keeps all or none of
+// them and Enabled can predict it; other lines are sampled at random.
+func sampleLog(level string, sc trace.SpanContext) bool {
+	ratio := globalCfg.LogSampleRatio
+	if ratio <= 0 || ratio >= 1 || parseLevel(level) > zapcore.InfoLevel {
+		return true
+	}
+	if sc.HasTraceID() {
+		id := sc.TraceID()
+		return float64(binary.BigEndian.Uint64(id[8:])>>11)/(1<<53) < ratio
+	}
+	return rand.Float64() < ratio
+}
+
+// SetLevel changes the minimum level emitted by all loggers at runtime.
+func SetLevel(level string) error {
+	lvl, ok := levels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	atomicLevel.SetLevel(lvl)
+	return nil
+}
+
+// GetLevel returns the current minimum level.
+func GetLevel() string {
+	return atomicLevel.Level().String()
+}
@@ -0,0 +1,150 @@
+package eotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, zapcore.DebugLevel, parseLevel("debug"))
+	assert.Equal(t, zapcore.WarnLevel, parseLevel("warn"))
+	assert.Equal(t, zapcore.ErrorLevel, parseLevel("error"))
+	assert.Equal(t, zapcore.InfoLevel, parseLevel("verbose"))
+}
+
+func TestLogLevelFiltersOutput(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LogLevel: "warn"})
+	spy := &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(spy))
+
+	logger.Info("dropped")
+	logger.Error("kept")
+
+	sent := spy.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "kept", sent[0].msg)
+	core := logger.(*Eotel).logger.Core()
+	assert.False(t, core.Enabled(zapcore.InfoLevel))
+	assert.True(t, core.Enabled(zapcore.ErrorLevel))
+}
+
+func TestSetLevelAppliesToExistingLoggers(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LogLevel: "info"})
+	spy := &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(spy))
+
+	logger.Debug("suppressed")
+	require.Empty(t, spy.Sent())
+
+	require.NoError(t, SetLevel("debug"))
+	assert.Equal(t, "debug", GetLevel())
+	logger.Debug("now visible")
+
+	sent := spy.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "now visible", sent[0].msg)
+	assert.True(t, logger.(*Eotel).logger.Core().Enabled(zapcore.DebugLevel))
+}
+
+func TestFilteredLevelDoesNoWork(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", LogLevel: "info"})
+	logger := New(context.Background(), "TestLogger")
+
+	logger.Debug("suppressed")
+	logger.Debugf("suppressed %d", 1)
+	logger.DebugAttrs("suppressed", map[string]any{"k": "v"})
+
+	assert.Empty(t, sr.Started())
+	assert.Zero(t, counterValue(t, reader, "log_total"))
+	assert.Nil(t, logger.(*Eotel).span)
+	assert.Zero(t, testing.AllocsPerRun(100, func() { logger.Debug("suppressed") }))
+}
+
+func BenchmarkSuppressedDebug(b *testing.B) {
+	prev := atomicLevel.Level()
+	atomicLevel.SetLevel(zapcore.InfoLevel)
+	b.Cleanup(func() { atomicLevel.SetLevel(prev) })
+	logger := New(context.Background(), "BenchLogger").WithField("order_id", 42)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Debugf("order %d shipped", 42)
+	}
+}
+
+func TestSetLevelRejectsUnknownLevel(t *testing.T) {
+	assert.Error(t, SetLevel("verbose"))
+}
+
+func TestLogSamplingKeepsErrors(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LogSampleRatio: 1e-9})
+	spy := &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(spy))
+
+	for i := 0; i < 100; i++ {
+		logger.Info("sampled out")
+		logger.Error("always kept")
+	}
+
+	sent := spy.Sent()
+	require.Len(t, sent, 100)
+	for _, s := range sent {
+		assert.Equal(t, "error", s.level)
+	}
+}
+
+func TestEnabledGuardsExpensiveFields(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", LogLevel: "info"})
+	logger, logs := newObservedLogger("TestLogger")
+
+	computed := 0
+	expensive := func() int { computed++; return 42 }
+	if logger.Enabled("debug") {
+		logger.WithField("state", expensive()).Debug("dump")
+	}
+	if logger.Enabled("info") {
+		logger.WithField("state", expensive()).Info("summary")
+	}
+	logger.InfoIf(false, "skipped")
+	logger.InfoIf(true, "written")
+
+	assert.False(t, logger.Enabled("debug"))
+	assert.Equal(t, 1, computed)
+	require.Len(t, logs.All(), 2)
+	assert.Equal(t, "summary", logs.All()[0].Message)
+	assert.Equal(t, "written", logs.All()[1].Message)
+}
+
+func TestEnabledPredictsTraceSampling(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", LogSampleRatio: 0.5})
+	newSpanRecorder(t)
+	logger, logs := newObservedLogger("TestLogger")
+
+	var kept, predicted int
+	for range 200 {
+		ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+		l := logger.WithContext(ctx)
+		if l.Enabled("info") {
+			predicted++
+		}
+		before := logs.Len()
+		l.Info("sampled")
+		l.Info("sampled again")
+		if n := logs.Len() - before; n > 0 {
+			assert.Equal(t, 2, n, "a trace keeps all of its lines or none")
+			kept++
+		}
+		assert.True(t, l.Enabled("warn"))
+		span.End()
+	}
+	assert.Equal(t, predicted, kept)
+	assert.Greater(t, kept, 0)
+	assert.Less(t, kept, 200)
+}
@@ -1,24 +1,68 @@
 package eotel
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"maps"
+	"math"
 	"os"
+	"reflect"
+	"slices"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type loggerCtxKey struct{}
+var exitFunc = os.Exit
+
+// setExitFunc replaces the function Fatal exits through and returns a func
+// restoring the previous one. It exists so tests can exercise Fatal.
+func setExitFunc(fn func(code int)) func() {
+	prev := exitFunc
+	exitFunc = fn
+	return func() { exitFunc = prev }
+}
+
+// deferredExit stops zap from exiting on fatal entries so that Fatal can
+// flush telemetry before calling exitFunc itself.
+type deferredExit struct{}
+
+func (deferredExit) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
+
+func fatalFlushTimeout() time.Duration {
+	if globalCfg.FatalFlushTimeout > 0 {
+		return globalCfg.FatalFlushTimeout
+	}
+	return 2 * time.Second
+}
 
 type Exporter interface {
 	Send(level string, msg string, traceID string, spanID string)
 	CaptureError(err error, tags map[string]string, extras map[string]any)
+	// CaptureMessage sends an event without an error value, e.g. a notable
+	// warning, at the given eotel level.
+	CaptureMessage(level, msg string, tags map[string]string)
+}
+
+// EntryExporter is an Exporter that receives the complete Loki entry,
+// including the labels added with WithLabel, instead of the bare Send
+// arguments.
+type EntryExporter interface {
+	Exporter
+	SendEntry(entry LokiEntry)
 }
 
 type Logger interface {
@@ -27,27 +71,78 @@ type Logger interface {
 	Debug(msg string)
 	Warn(msg string)
 	Fatal(msg string)
+	InfoCtx(ctx context.Context, msg string)
+	ErrorCtx(ctx context.Context, msg string)
+	DebugCtx(ctx context.Context, msg string)
+	WarnCtx(ctx context.Context, msg string)
+	FatalCtx(ctx context.Context, msg string)
+	Infof(format string, args ...any)
+	Errorf(format string, args ...any)
+	Debugf(format string, args ...any)
+	Warnf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	InfoIf(cond bool, msg string)
+	Enabled(level string) bool
+	InfoAttrs(msg string, fields map[string]any)
+	ErrorAttrs(msg string, fields map[string]any)
+	DebugAttrs(msg string, fields map[string]any)
+	WarnAttrs(msg string, fields map[string]any)
+	FatalAttrs(msg string, fields map[string]any)
 
 	WithField(key string, value any) Logger
 	WithFields(map[string]any) Logger
+	WithStr(key, value string) Logger
+	WithInt(key string, value int) Logger
+	WithFloat(key string, value float64) Logger
+	WithBool(key string, value bool) Logger
+	WithDuration(key string, value time.Duration) Logger
 	WithError(err error) Logger
+	WithStack() Logger
+	WithoutStack() Logger
+	WithBaggage(key, value string) Logger
+	WithLabel(key, value string) Logger
+	WithRequestID(id string) Logger
+	WithContext(ctx context.Context) Logger
 	WithTracer(name string, fn func(ctx context.Context))
+	WithSpan(name string, fn func(Logger) error) error
 	SpanEvent(name string, attrs ...attribute.KeyValue)
 	SetSpanAttr(key string, value any)
-	SetSpanError(err error)
+	SetSpanError(err error, attrs ...attribute.KeyValue)
+	CaptureMessage(level, msg string)
 	Child(name string) Logger
+	ChildKind(name string, kind trace.SpanKind) Logger
+	StartLinked(name string, links ...trace.Link) Logger
+	StartRoot(name string) Logger
+	End()
+	Sync() error
+	Clone() Logger
 	Ctx() context.Context
 	Start(name string) Timer
+	Counter(name string, value int64, attrs ...attribute.KeyValue)
+	Gauge(name string, value float64, attrs ...attribute.KeyValue)
+	Histogram(name string, value float64, attrs ...attribute.KeyValue)
+	TraceID() string
+	RequestID() string
+	SpanID() string
 
 	Inject(ctx context.Context, logger Logger) context.Context
 	FromContext(ctx context.Context, name string) Logger
+	FromContextOK(ctx context.Context, name string) (Logger, bool)
 	FromGin(c *gin.Context, name string) Logger
 	InjectToGin(c *gin.Context, logger Logger)
 	RecoverPanic(c *gin.Context) func()
 }
 
 type Timer interface {
-	Stop()
+	// Stop adds a span event for the timed operation, records its duration in
+	// the operation_duration_ms histogram and returns it.
+	Stop() time.Duration
+	// StopWithError is Stop for an operation that may have failed: a non-nil
+	// err is recorded on the span event and counted in
+	// operation_errors_total. To report a named return value, defer a
+	// closure (defer func() { t.StopWithError(err) }()) so err is read when
+	// the function returns rather than when the defer is declared.
+	StopWithError(err error) time.Duration
 }
 
 type Eotel struct {
@@ -56,136 +151,577 @@ type Eotel struct {
 	tracer       trace.Tracer
 	meter        metric.Meter
 	span         trace.Span
+	ownSpan      bool
 	logCounter   metric.Int64Counter
 	durationHist metric.Float64Histogram
+	lifetimeHist metric.Float64Histogram
+	errorCounter metric.Int64Counter
+	messageBytes metric.Int64Histogram
 	fields       []zap.Field
 	attrs        []attribute.KeyValue
 	err          error
+	errStack     []uintptr
+	stack        stackPolicy
 	name         string
 	start        time.Time
 	exporter     Exporter
+	crumbs       *breadcrumbs
+	labels       map[string]string
+	requestID    string
+	hooks        []func(LogRecord)
+	ended        bool
+}
+
+// Tracer returns the tracer eotel starts its spans with, for integrations
+// that need to start a request span themselves.
+func Tracer() trace.Tracer {
+	return otel.Tracer(globalCfg.ServiceName)
+}
+
+type Option func(*Eotel)
+
+// WithExporter routes the logger's Loki sends and error captures to e instead
+// of the default Loki/Sentry exporter. Children inherit it.
+func WithExporter(e Exporter) Option {
+	return func(l *Eotel) {
+		l.exporter = e
+	}
 }
 
-func New(ctx context.Context, name string) Logger {
+// WithTracerProvider starts the logger's spans, and its children's, from tp
+// instead of the global tracer provider, e.g. a test's in-memory recorder.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(l *Eotel) {
+		l.tracer = tp.Tracer(globalCfg.ServiceName)
+	}
+}
+
+// WithZapLogger makes the logger, and its children, write through zl instead
+// of the zap logger InitEOTEL set up (or zap.L() before it), e.g. one with
+// custom cores or sinks.
+func WithZapLogger(zl *zap.Logger) Option {
+	return func(l *Eotel) {
+		if zl != nil {
+			l.logger = zl
+		}
+	}
+}
+
+func New(ctx context.Context, name string, opts ...Option) Logger {
 	meter := otel.Meter(globalCfg.ServiceName)
-	logCounter, durationHist := initMetrics(meter)
-	return &Eotel{
+	crumbs := &breadcrumbs{}
+	l := &Eotel{
 		ctx:          ctx,
-		logger:       zap.L(),
-		tracer:       otel.Tracer(globalCfg.ServiceName),
+		logger:       zapLogger(),
+		tracer:       Tracer(),
 		meter:        meter,
-		logCounter:   logCounter,
-		durationHist: durationHist,
+		logCounter:   int64Counter(meter, "log_total"),
+		durationHist: float64Histogram(meter, "log_duration_ms"),
+		lifetimeHist: float64Histogram(meter, "logger_lifetime_ms"),
+		errorCounter: int64Counter(meter, "errors_total"),
+		messageBytes: int64Histogram(meter, "log_message_bytes"),
 		start:        time.Now(),
-		exporter:     defaultExporter{},
+		exporter:     defaultExporter{crumbs: crumbs},
+		crumbs:       crumbs,
 		name:         name,
 	}
+	if len(globalCfg.Exporters) > 0 {
+		l.exporter = MultiExporter(append([]Exporter{l.exporter}, globalCfg.Exporters...)...)
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 func (l *Eotel) Inject(ctx context.Context, logger Logger) context.Context {
-	return context.WithValue(ctx, loggerCtxKey{}, logger)
+	return Inject(ctx, logger)
 }
 
 func (l *Eotel) FromContext(ctx context.Context, name string) Logger {
-	if val := ctx.Value(loggerCtxKey{}); val != nil {
-		if lg, ok := val.(*Eotel); ok {
-			return lg
-		}
-	}
-	return New(ctx, name)
+	return FromContext(ctx, name)
+}
+
+func (l *Eotel) FromContextOK(ctx context.Context, name string) (Logger, bool) {
+	return FromContextOK(ctx, name)
 }
 
 func (l *Eotel) FromGin(c *gin.Context, name string) Logger {
-	return l.FromContext(c.Request.Context(), name)
+	return FromGin(c, name)
 }
 
 func (l *Eotel) InjectToGin(c *gin.Context, logger Logger) {
-	ctx := l.Inject(c.Request.Context(), logger)
-	c.Request = c.Request.WithContext(ctx)
+	InjectToGin(c, logger)
 }
 
 func (l *Eotel) RecoverPanic(c *gin.Context) func() {
 	return func() {
 		if rec := recover(); rec != nil {
-			err := fmt.Errorf("panic: %v", rec)
+			err := NewPanicError(rec)
 
-			log := l.FromGin(c, "panic")
-			if log == nil {
-				log = l
-			}
-
-			log.WithError(err).Error("unhandled panic")
+			FromGin(c, "panic").WithError(err).Error("unhandled panic")
 			c.AbortWithStatus(500)
 		}
 	}
 }
 
-func (l *Eotel) Info(msg string)  { l.log("info", msg) }
-func (l *Eotel) Error(msg string) { l.log("error", msg) }
-func (l *Eotel) Debug(msg string) { l.log("debug", msg) }
-func (l *Eotel) Warn(msg string)  { l.log("warn", msg) }
-func (l *Eotel) Fatal(msg string) {
-	l.log("fatal", msg)
-	if l.span != nil {
+func (l *Eotel) Info(msg string)  { l.InfoCtx(l.ctx, msg) }
+func (l *Eotel) Error(msg string) { l.ErrorCtx(l.ctx, msg) }
+func (l *Eotel) Debug(msg string) { l.DebugCtx(l.ctx, msg) }
+func (l *Eotel) Warn(msg string)  { l.WarnCtx(l.ctx, msg) }
+func (l *Eotel) Fatal(msg string) { l.FatalCtx(l.ctx, msg) }
+
+func (l *Eotel) InfoCtx(ctx context.Context, msg string)  { l.log(ctx, "info", msg, nil) }
+func (l *Eotel) ErrorCtx(ctx context.Context, msg string) { l.log(ctx, "error", msg, nil) }
+func (l *Eotel) DebugCtx(ctx context.Context, msg string) { l.log(ctx, "debug", msg, nil) }
+func (l *Eotel) WarnCtx(ctx context.Context, msg string)  { l.log(ctx, "warn", msg, nil) }
+func (l *Eotel) FatalCtx(ctx context.Context, msg string) { l.fatal(ctx, msg, nil) }
+
+// InfoAttrs and its siblings log msg with fields added to this line only;
+// unlike WithFields they leave the logger itself unchanged.
+func (l *Eotel) InfoAttrs(msg string, fields map[string]any)  { l.log(l.ctx, "info", msg, fields) }
+func (l *Eotel) ErrorAttrs(msg string, fields map[string]any) { l.log(l.ctx, "error", msg, fields) }
+func (l *Eotel) DebugAttrs(msg string, fields map[string]any) { l.log(l.ctx, "debug", msg, fields) }
+func (l *Eotel) WarnAttrs(msg string, fields map[string]any)  { l.log(l.ctx, "warn", msg, fields) }
+func (l *Eotel) FatalAttrs(msg string, fields map[string]any) { l.fatal(l.ctx, msg, fields) }
+
+func (l *Eotel) fatal(ctx context.Context, msg string, fields map[string]any) {
+	l.log(ctx, "fatal", msg, fields)
+	if l.span != nil && l.ownSpan {
 		l.span.End()
 	}
-	os.Exit(1)
+	// Flush rather than Shutdown: the exit is what ends the process, and an
+	// exitFunc that returns, e.g. in tests, leaves telemetry working.
+	flushCtx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout())
+	_ = Flush(flushCtx)
+	cancel()
+	exitFunc(1)
 }
 
-func (l *Eotel) log(level, msg string) {
-	l.startSpanIfNeeded()
-	sc := l.span.SpanContext()
+func (l *Eotel) Infof(format string, args ...any)  { l.logf("info", format, args) }
+func (l *Eotel) Errorf(format string, args ...any) { l.logf("error", format, args) }
+func (l *Eotel) Debugf(format string, args ...any) { l.logf("debug", format, args) }
+func (l *Eotel) Warnf(format string, args ...any)  { l.logf("warn", format, args) }
+func (l *Eotel) Fatalf(format string, args ...any) { l.Fatal(fmt.Sprintf(format, args...)) }
+
+// Enabled reports whether a line logged at level would be written, for
+// guarding work that only feeds log fields. It applies LogLevel and, for a
+// logger inside a trace, LogSampleRatio; outside a trace sampling is random
+// per line, so Enabled can only rule out filtered levels.
+func (l *Eotel) Enabled(level string) bool {
+	if !levelEnabled(level) {
+		return false
+	}
+	sc := l.sampleSpanContext(l.ctx)
+	return !sc.HasTraceID() || sampleLog(level, sc)
+}
+
+// InfoIf logs msg at info level when cond is true.
+func (l *Eotel) InfoIf(cond bool, msg string) {
+	if cond {
+		l.Info(msg)
+	}
+}
+
+// sampleSpanContext returns the span context whose trace a line logged with
+// ctx would belong to, without starting a span. It is invalid when the
+// logger would have to start one.
+func (l *Eotel) sampleSpanContext(ctx context.Context) trace.SpanContext {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc
+	}
+	return l.activeSpan().SpanContext()
+}
+
+// logf skips formatting the message when level is filtered out.
+func (l *Eotel) logf(level, format string, args []any) {
+	if levelEnabled(level) {
+		l.log(l.ctx, level, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// fieldPool recycles the scratch slices log assembles each line's fields in.
+// zap gets a copy, since a core passed to WithZapLogger may keep the fields
+// it is given.
+var fieldPool = sync.Pool{
+	New: func() any {
+		fields := make([]zap.Field, 0, 16)
+		return &fields
+	},
+}
+
+// staticFields caches the job and service fields for the configuration they
+// were built from.
+type staticFields struct {
+	job, service string
+	fields       [2]zap.Field
+}
+
+var cachedStaticFields atomic.Pointer[staticFields]
+
+// serviceFields returns the job and service fields every line carries,
+// building them again only when the configuration has changed.
+func serviceFields() []zap.Field {
+	s := cachedStaticFields.Load()
+	if s == nil || s.job != globalCfg.JobName || s.service != globalCfg.ServiceName {
+		s = &staticFields{
+			job:     globalCfg.JobName,
+			service: globalCfg.ServiceName,
+			fields:  [2]zap.Field{zap.String("job", globalCfg.JobName), zap.String("service", globalCfg.ServiceName)},
+		}
+		cachedStaticFields.Store(s)
+	}
+	return s.fields[:]
+}
+
+func (l *Eotel) log(ctx context.Context, level, msg string, oneShot map[string]any) {
+	// Filtered lines stop here, before a span is started or anything is
+	// recorded.
+	if !levelEnabled(level) || !sampleLog(level, l.sampleSpanContext(ctx)) {
+		return
+	}
+	span, owned := l.spanFor(ctx)
+	sc := span.SpanContext()
 	traceID := sc.TraceID().String()
 
-	fields := append([]zap.Field{
-		zap.String("trace_id", traceID),
-		zap.String("span_id", sc.SpanID().String()),
-		zap.String("job", globalCfg.JobName),
-		zap.String("service", globalCfg.ServiceName),
-		zap.String("level", level),
-	}, l.fields...)
+	buf := fieldPool.Get().(*[]zap.Field)
+	fields := append((*buf)[:0], zap.String("trace_id", traceID), zap.String("span_id", sc.SpanID().String()))
+	fields = append(fields, serviceFields()...)
+	fields = append(fields, zap.String("level", level))
+	header := len(fields)
+	fields = append(fields, l.fields...)
+	defer func() {
+		// Only zap may keep fields past this call, and it gets a copy; Loki,
+		// Sentry and OnLog hooks all copy what they need before returning.
+		clear(fields)
+		*buf = fields[:0]
+		fieldPool.Put(buf)
+	}()
+	for _, m := range baggage.FromContext(ctx).Members() {
+		fields = append(fields, zap.String(m.Key(), m.Value()))
+	}
+	var extra []attribute.KeyValue
+	for _, k := range sortedKeys(oneShot) {
+		v := redact(k, oneShot[k])
+		fields = append(fields, zap.Any(k, v))
+		extra = append(extra, attributeOf(k, v))
+	}
+	if l.err != nil && l.shouldCaptureStack(level) {
+		if stack := errorStack(l.err, l.errStack); stack != "" {
+			fields = append(fields, zap.String("stacktrace", stack))
+			extra = append(extra, attribute.String("stacktrace", stack))
+		}
+	}
+	if globalCfg.RecordContextErrors {
+		if attr, ok := contextErrAttr(ctx); ok {
+			fields = append(fields, zap.Bool(string(attr.Key), true))
+			extra = append(extra, attr)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				span.SetStatus(codes.Error, ctx.Err().Error())
+			}
+		}
+	}
 
+	zapFields := slices.Clone(fields)
 	switch level {
 	case "info":
-		l.logger.Info(msg, fields...)
+		l.logger.Info(msg, zapFields...)
 	case "error":
-		l.logger.Error(msg, fields...)
+		l.logger.Error(msg, zapFields...)
 	case "debug":
-		l.logger.Debug(msg, fields...)
+		l.logger.Debug(msg, zapFields...)
 	case "warn":
-		l.logger.Warn(msg, fields...)
+		l.logger.Warn(msg, zapFields...)
 	case "fatal":
-		l.logger.Fatal(msg, fields...)
+		l.logger.WithOptions(zap.WithFatalHook(deferredExit{})).Fatal(msg, zapFields...)
 	}
 
-	if globalCfg.EnableLoki {
-		l.exporter.Send(level, msg, traceID, sc.SpanID().String())
+	if len(l.hooks) > 0 {
+		record := LogRecord{
+			Time:    time.Now(),
+			Level:   level,
+			Message: msg,
+			TraceID: traceID,
+			SpanID:  sc.SpanID().String(),
+			Fields:  logRecordFields(fields[header:]),
+		}
+		for _, hook := range l.hooks {
+			hook(record)
+		}
 	}
 
-	l.endSpan(msg, level)
+	if globalCfg.EnableLoki && shouldPushLoki(level) {
+		if e, ok := l.exporter.(EntryExporter); ok {
+			e.SendEntry(newLokiEntry(level, msg, traceID, sc.SpanID().String(), l.labels, lokiMetadata(fields[header:])))
+		} else {
+			l.exporter.Send(level, msg, traceID, sc.SpanID().String())
+		}
+	}
+
+	if l.err != nil && shouldCapture(level) {
+		// The event carries the line's fields, already redacted, as extras.
+		extras := logRecordFields(fields[header:])
+		extras["error"] = l.err.Error()
+		l.exporter.CaptureError(l.err, sentryTags(sc), extras)
+	}
+	switch level {
+	case "debug", "info", "warn":
+		l.crumbs.add(level, msg)
+	}
+
+	l.endSpan(ctx, span, owned, msg, level, extra...)
+}
+
+// contextErrAttr reports whether ctx was cancelled or timed out by the time a
+// line is logged, as the attribute to flag it with.
+func contextErrAttr(ctx context.Context) (attribute.KeyValue, bool) {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return attribute.KeyValue{}, false
+	case errors.Is(err, context.DeadlineExceeded):
+		return attribute.Bool("context.deadline_exceeded", true), true
+	default:
+		return attribute.Bool("context.cancelled", true), true
+	}
+}
+
+// shouldCapture reports whether an attached error logged at level is sent to
+// Sentry, according to SentryCaptureLevel.
+func shouldCapture(level string) bool {
+	threshold := globalCfg.SentryCaptureLevel
+	if threshold == "" {
+		threshold = "error"
+	}
+	return parseLevel(level) >= parseLevel(threshold)
+}
+
+// shouldPushLoki reports whether a line logged at level is sent to Loki,
+// according to LokiMinLevel.
+func shouldPushLoki(level string) bool {
+	if globalCfg.LokiMinLevel == "" {
+		return true
+	}
+	return parseLevel(level) >= parseLevel(globalCfg.LokiMinLevel)
 }
 
 func (l *Eotel) WithField(key string, value any) Logger {
-	l.fields = append(l.fields, zap.Any(key, value))
-	l.attrs = append(l.attrs, attribute.String(key, fmt.Sprintf("%v", value)))
-	return l
+	c := l.clone()
+	c.addField(key, value)
+	return c
 }
 
 func (l *Eotel) WithFields(m map[string]any) Logger {
-	for k, v := range m {
-		l.WithField(k, v)
+	c := l.clone()
+	for _, k := range sortedKeys(m) {
+		c.addField(k, m[k])
 	}
-	return l
+	return c
+}
+
+// WithStr, WithInt, WithFloat, WithBool and WithDuration are WithField for
+// values of a known type. They build the log field and span attribute
+// directly, without boxing the value, unless a Redactor has to see it.
+func (l *Eotel) WithStr(key, value string) Logger {
+	if globalCfg.Redactor != nil {
+		return l.WithField(key, value)
+	}
+	return l.withTyped(zap.String(key, value), attribute.String(key, value))
+}
+
+func (l *Eotel) WithInt(key string, value int) Logger {
+	if globalCfg.Redactor != nil {
+		return l.WithField(key, value)
+	}
+	return l.withTyped(zap.Int(key, value), attribute.Int(key, value))
+}
+
+func (l *Eotel) WithFloat(key string, value float64) Logger {
+	if globalCfg.Redactor != nil {
+		return l.WithField(key, value)
+	}
+	return l.withTyped(zap.Float64(key, value), attribute.Float64(key, value))
+}
+
+func (l *Eotel) WithBool(key string, value bool) Logger {
+	if globalCfg.Redactor != nil {
+		return l.WithField(key, value)
+	}
+	return l.withTyped(zap.Bool(key, value), attribute.Bool(key, value))
+}
+
+func (l *Eotel) WithDuration(key string, value time.Duration) Logger {
+	if globalCfg.Redactor != nil {
+		return l.WithField(key, value)
+	}
+	return l.withTyped(zap.Duration(key, value), attribute.String(key, value.String()))
+}
+
+// withTyped adds a prebuilt field and attribute, replacing both with "***"
+// when the key is one of RedactKeys.
+func (l *Eotel) withTyped(field zap.Field, attr attribute.KeyValue) Logger {
+	if redactedKey(field.Key) {
+		field, attr = zap.String(field.Key, redacted), attribute.String(field.Key, redacted)
+	}
+	c := l.clone()
+	c.fields = append(c.fields, field)
+	c.attrs = append(c.attrs, attr)
+	return c
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (l *Eotel) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	c := l.clone()
+	c.err = err
+	c.errStack = nil
+	if c.capturesStack() {
+		c.errStack = callers(1)
+	}
+	c.fields = append(c.fields, zap.Error(err))
+	c.attrs = append(c.attrs, attribute.String("error", err.Error()))
+	return c
+}
+
+// WithBaggage sets an OTEL baggage member on the logger's context. Baggage
+// members are logged as fields and span attributes, and travel with the
+// context to children and downstream services.
+func (l *Eotel) WithBaggage(key, value string) Logger {
+	member, err := baggage.NewMemberRaw(key, value)
 	if err != nil {
-		l.err = err
-		l.fields = append(l.fields, zap.Error(err))
-		l.attrs = append(l.attrs, attribute.String("error", err.Error()))
-		l.exporter.CaptureError(err, map[string]string{}, map[string]any{"error": err.Error()})
+		return l
 	}
-	return l
+	bag, err := baggage.FromContext(l.ctx).SetMember(member)
+	if err != nil {
+		return l
+	}
+	c := l.clone()
+	c.ctx = baggage.ContextWithBaggage(l.ctx, bag)
+	return c
+}
+
+// WithLabel returns a copy of the logger whose Loki entries carry the stream
+// label key=value. Every distinct value makes a new Loki stream, so use it
+// only for low-cardinality values such as a tenant; at most maxLokiLabels
+// labels are kept and reserved labels cannot be replaced.
+func (l *Eotel) WithLabel(key, value string) Logger {
+	if slices.Contains(lokiReservedLabels, key) {
+		zapLogger().Warn("eotel: ignoring reserved Loki label", zap.String("label", key))
+		return l
+	}
+	if _, ok := l.labels[key]; !ok && len(l.labels) >= maxLokiLabels {
+		zapLogger().Warn("eotel: too many Loki labels, ignoring label", zap.String("label", key), zap.Int("max", maxLokiLabels))
+		return l
+	}
+	c := l.clone()
+	c.labels = maps.Clone(l.labels)
+	if c.labels == nil {
+		c.labels = map[string]string{}
+	}
+	c.labels[key] = value
+	return c
+}
+
+// WithContext returns a copy of the logger bound to ctx, keeping its fields,
+// error, exporter and instruments. A span active in ctx becomes the logger's
+// span; otherwise the logger's current span, if it has one, is carried into
+// ctx. The copy never ends a span it did not start itself.
+func (l *Eotel) WithContext(ctx context.Context) Logger {
+	c := l.clone()
+	c.ownSpan = false
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		c.span = span
+	} else if l.span != nil {
+		ctx = trace.ContextWithSpan(ctx, l.span)
+	}
+	c.ctx = ctx
+	return c
+}
+
+func (l *Eotel) addField(key string, value any) {
+	value = redact(key, value)
+	l.fields = append(l.fields, zap.Any(key, value))
+	l.attrs = append(l.attrs, attributeOf(key, value))
+}
+
+// attributeOf converts a field value to a span attribute, keeping numbers,
+// bools and slices typed so they stay queryable in the tracing backend.
+// Other values are stringified.
+func attributeOf(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int8:
+		return attribute.Int64(key, int64(v))
+	case int16:
+		return attribute.Int64(key, int64(v))
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case int64:
+		return attribute.Int64(key, v)
+	case uint8:
+		return attribute.Int64(key, int64(v))
+	case uint16:
+		return attribute.Int64(key, int64(v))
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return attribute.Int64(key, int64(v))
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return attribute.Int64(key, int64(v))
+		}
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case []bool:
+		return attribute.BoolSlice(key, v)
+	case []int:
+		return attribute.IntSlice(key, v)
+	case []int64:
+		return attribute.Int64Slice(key, v)
+	case []float64:
+		return attribute.Float64Slice(key, v)
+	case error:
+		return attribute.String(key, v.Error())
+	case fmt.Stringer:
+		return attribute.String(key, v.String())
+	}
+	return attribute.String(key, fmt.Sprintf("%v", value))
+}
+
+// Clone returns an independent copy of the logger: it shares the context,
+// span, exporter and instruments but owns its fields, so adding to one never
+// shows up in the other.
+func (l *Eotel) Clone() Logger {
+	return l.clone()
+}
+
+// clone returns a shallow copy of l with its own fields and attrs so that
+// derived loggers never share (or race on) the parent's slices.
+func (l *Eotel) clone() *Eotel {
+	c := *l
+	c.fields = append([]zap.Field(nil), l.fields...)
+	c.attrs = append([]attribute.KeyValue(nil), l.attrs...)
+	return &c
 }
 
 func (l *Eotel) WithTracer(name string, fn func(ctx context.Context)) {
@@ -194,41 +730,200 @@ func (l *Eotel) WithTracer(name string, fn func(ctx context.Context)) {
 	fn(ctx)
 }
 
-func (l *Eotel) SpanEvent(name string, attrs ...attribute.KeyValue) {
-	if l.span != nil {
-		l.span.AddEvent(name, trace.WithAttributes(attrs...))
+// WithSpan runs fn with a child logger bound to a new span named name. The
+// span stays open while fn logs through it and is ended when fn returns; an
+// error returned by fn is recorded on it and marks it as failed. The error
+// is returned unchanged.
+func (l *Eotel) WithSpan(name string, fn func(Logger) error) error {
+	c := l.child(name)
+	c.ownSpan = false
+	span := c.span
+	defer span.End()
+
+	err := fn(c)
+	if err != nil {
+		failSpan(span, err)
 	}
+	return err
+}
+
+func (l *Eotel) SpanEvent(name string, attrs ...attribute.KeyValue) {
+	l.activeSpan().AddEvent(name, trace.WithAttributes(attrs...))
 }
 
 func (l *Eotel) SetSpanAttr(key string, value any) {
-	if l.span != nil {
-		l.span.SetAttributes(attribute.String(key, fmt.Sprintf("%v", value)))
+	l.activeSpan().SetAttributes(attributeOf(key, redact(key, value)))
+}
+
+// SetSpanError records err on the active span, with attrs on its exception
+// event, and marks the span as failed.
+func (l *Eotel) SetSpanError(err error, attrs ...attribute.KeyValue) {
+	if err != nil {
+		failSpan(l.activeSpan(), err, attrs...)
+	}
+}
+
+// CaptureMessage sends msg to Sentry at level through the logger's exporter,
+// tagged with the active trace like captured errors.
+func (l *Eotel) CaptureMessage(level, msg string) {
+	l.exporter.CaptureMessage(level, msg, sentryTags(l.activeSpan().SpanContext()))
+}
+
+// failSpan records err on span and sets the span status to Error.
+func failSpan(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	recordError(span, err, attrs...)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// recordError records err on span, adds an "error.cause" event for each error
+// it wraps and sets error.type to the concrete type of the root cause.
+func recordError(span trace.Span, err error, attrs ...attribute.KeyValue) {
+	span.RecordError(err, trace.WithAttributes(attrs...))
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		span.AddEvent("error.cause", trace.WithAttributes(
+			attribute.String("error.type", errorType(cause)),
+			attribute.String("error.message", cause.Error()),
+		))
+	}
+	span.SetAttributes(attribute.String("error.type", errorType(rootCause(err))))
+}
+
+// rootCause returns the innermost error wrapped by err.
+func rootCause(err error) error {
+	for {
+		cause := errors.Unwrap(err)
+		if cause == nil {
+			return err
+		}
+		err = cause
 	}
 }
 
-func (l *Eotel) SetSpanError(err error) {
-	if err != nil && l.span != nil {
-		l.span.RecordError(err)
+func errorType(err error) string {
+	return reflect.TypeOf(err).String()
+}
+
+// activeSpan returns the logger's span, or the span carried by its context
+// when it has not bound one yet. It never starts a span.
+func (l *Eotel) activeSpan() trace.Span {
+	if l.span != nil {
+		return l.span
 	}
+	return trace.SpanFromContext(l.ctx)
 }
 
+// Child starts a span named name under the logger's context and returns a
+// logger bound to it. The child keeps the parent's fields but not its error.
 func (l *Eotel) Child(name string) Logger {
-	ctx, span := l.tracer.Start(l.ctx, name)
-	return &Eotel{
-		ctx:      ctx,
-		span:     span,
-		logger:   l.logger,
-		tracer:   l.tracer,
-		meter:    l.meter,
-		start:    time.Now(),
-		exporter: l.exporter,
+	return l.child(name)
+}
+
+// ChildKind is like Child but starts the span with the given kind, e.g.
+// trace.SpanKindProducer when publishing a message or trace.SpanKindClient
+// for an outbound call.
+func (l *Eotel) ChildKind(name string, kind trace.SpanKind) Logger {
+	return l.child(name, trace.WithSpanKind(kind))
+}
+
+// StartLinked is like Child but the new span also links to the given span
+// contexts, e.g. the span that enqueued the job being processed. See
+// LinkFromHeaders for recovering such a link from message headers.
+func (l *Eotel) StartLinked(name string, links ...trace.Link) Logger {
+	return l.child(name, trace.WithLinks(links...))
+}
+
+// StartRoot is like Child but the new span starts a trace of its own instead
+// of joining the logger's, e.g. for a background job kicked off by a request
+// that should not be counted in the request's trace. The logger keeps its
+// fields and baggage.
+func (l *Eotel) StartRoot(name string) Logger {
+	return l.child(name, trace.WithNewRoot())
+}
+
+func (l *Eotel) child(name string, opts ...trace.SpanStartOption) *Eotel {
+	c := l.clone()
+	c.ctx, c.span = l.tracer.Start(l.ctx, name, opts...)
+	c.ownSpan = true
+	c.name = name
+	c.start = time.Now()
+	c.clearError()
+	return c
+}
+
+func (l *Eotel) clearError() {
+	if l.err == nil {
+		return
+	}
+	l.err = nil
+	l.errStack = nil
+	fields := l.fields[:0]
+	for _, f := range l.fields {
+		if f.Type != zapcore.ErrorType {
+			fields = append(fields, f)
+		}
+	}
+	l.fields = fields
+	attrs := l.attrs[:0]
+	for _, a := range l.attrs {
+		if a.Key != "error" {
+			attrs = append(attrs, a)
+		}
 	}
+	l.attrs = attrs
+}
+
+// End ends the logger's own span, if it started one, and records the
+// logger's lifetime in logger_lifetime_ms and on that span as duration_ms.
+// A span adopted from the logger's context is left for its creator to end.
+// Only the first call does anything, so End can be deferred right after New.
+func (l *Eotel) End() {
+	if l.ended {
+		return
+	}
+	l.ended = true
+	durationMs := time.Since(l.start).Seconds() * 1000
+	if l.span != nil && l.ownSpan {
+		l.span.SetAttributes(attribute.Float64("duration_ms", durationMs))
+		l.span.End()
+	}
+	l.lifetimeHist.Record(l.ctx, durationMs)
+}
+
+// Sync flushes any log lines zap has buffered, e.g. before the process
+// crashes or exits. See syncZap for the errors it ignores.
+func (l *Eotel) Sync() error {
+	return syncZap(l.logger)
 }
 
 func (l *Eotel) Ctx() context.Context {
 	return l.ctx
 }
 
+// TraceID returns the trace ID of the logger's active span, starting the span
+// if needed. It is empty when tracing is disabled.
+func (l *Eotel) TraceID() string {
+	sc := l.spanContext()
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// SpanID returns the span ID of the logger's active span, starting the span
+// if needed. It is empty when tracing is disabled.
+func (l *Eotel) SpanID() string {
+	sc := l.spanContext()
+	if !sc.HasSpanID() {
+		return ""
+	}
+	return sc.SpanID().String()
+}
+
+func (l *Eotel) spanContext() trace.SpanContext {
+	span, _ := l.spanFor(l.ctx)
+	return span.SpanContext()
+}
+
 func (l *Eotel) Start(name string) Timer {
 	start := time.Now()
 	return &eotelTimer{name: name, logger: l, start: start}
@@ -240,61 +935,153 @@ type eotelTimer struct {
 	start  time.Time
 }
 
-func (t *eotelTimer) Stop() {
-	duration := time.Since(t.start).Seconds() * 1000
-	t.logger.SpanEvent(t.name, attribute.Float64("custom.duration_ms", duration))
+func (t *eotelTimer) Stop() time.Duration {
+	return t.StopWithError(nil)
+}
+
+func (t *eotelTimer) StopWithError(err error) time.Duration {
+	elapsed := time.Since(t.start)
+	durationMs := float64(elapsed) / float64(time.Millisecond)
+	operation := attribute.String("operation", t.name)
+
+	attrs := []attribute.KeyValue{attribute.Float64("custom.duration_ms", durationMs)}
+	if err != nil {
+		attrs = append(attrs,
+			attribute.String("error", err.Error()),
+			attribute.String("error.type", errorType(rootCause(err))),
+		)
+		t.logger.Counter("operation_errors_total", 1, operation)
+	}
+	t.logger.SpanEvent(t.name, attrs...)
+	t.logger.Histogram("operation_duration_ms", durationMs, operation)
+	return elapsed
 }
 
+// startSpanIfNeeded binds the logger to a span. A valid span already active
+// in the logger's context (e.g. the middleware's request span) is adopted;
+// only when there is none does the logger start, and own, a span of its own.
 func (l *Eotel) startSpanIfNeeded() {
-	if l.span == nil {
-		l.ctx, l.span = l.tracer.Start(l.ctx, l.name)
+	if l.span != nil {
+		return
+	}
+	if span := trace.SpanFromContext(l.ctx); span.SpanContext().IsValid() {
+		l.span = span
+		return
 	}
+	l.ctx, l.span = l.tracer.Start(l.ctx, l.name)
+	l.ownSpan = true
 }
 
-func (l *Eotel) endSpan(msg, level string) {
+// spanFor returns the span a log line is recorded under: the active span
+// carried by ctx when there is one, otherwise the logger's own span. The
+// second result reports whether the span belongs to the logger.
+func (l *Eotel) spanFor(ctx context.Context) (trace.Span, bool) {
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		return span, l.ownSpan && span.SpanContext().Equal(l.span.SpanContext())
+	}
+	l.startSpanIfNeeded()
+	return l.span, l.ownSpan
+}
+
+func (l *Eotel) endSpan(ctx context.Context, span trace.Span, owned bool, msg, level string, extra ...attribute.KeyValue) {
 	durationMs := time.Since(l.start).Seconds() * 1000
-	l.attrs = append(l.attrs,
+	attrs := append(append([]attribute.KeyValue(nil), l.attrs...),
 		attribute.String("log.message", msg),
 		attribute.String("log.level", level),
 		attribute.Float64("duration_ms", durationMs),
 	)
-	sort.SliceStable(l.attrs, func(i, j int) bool {
-		return string(l.attrs[i].Key) < string(l.attrs[j].Key)
+	attrs = append(attrs, extra...)
+	for _, m := range baggage.FromContext(ctx).Members() {
+		attrs = append(attrs, attribute.String(m.Key(), m.Value()))
+	}
+	slices.SortStableFunc(attrs, func(a, b attribute.KeyValue) int {
+		return cmp.Compare(a.Key, b.Key)
 	})
 
-	if l.span != nil {
-		l.span.SetAttributes(l.attrs...)
+	span.SetAttributes(attrs...)
+	if l.err != nil {
+		// An error attached to a line below error level is context, not a
+		// failure of the operation.
+		if parseLevel(level) >= zapcore.ErrorLevel {
+			failSpan(span, l.err)
+		} else {
+			recordError(span, l.err)
+		}
+	}
+	if owned {
+		span.End()
+	}
+
+	levelAttr := metric.WithAttributes(attribute.String("level", level))
+	l.logCounter.Add(ctx, 1, levelAttr)
+	l.durationHist.Record(ctx, durationMs, levelAttr)
+	l.messageBytes.Record(ctx, int64(len(msg)), levelAttr)
+	if parseLevel(level) >= zapcore.ErrorLevel {
+		errAttrs := []attribute.KeyValue{attribute.String("level", level)}
 		if l.err != nil {
-			l.span.RecordError(l.err)
+			errAttrs = append(errAttrs, attribute.String("error.type", errorType(rootCause(l.err))))
 		}
-		l.span.End()
+		l.errorCounter.Add(ctx, 1, metric.WithAttributes(errAttrs...))
+	}
+}
+
+// int64Counter, int64Histogram and float64Histogram create the logger's
+// instruments. Failures are reported to the OTEL error handler and replaced
+// with no-op instruments, so a broken meter never makes logging panic.
+func int64Counter(m metric.Meter, name string) metric.Int64Counter {
+	c, err := m.Int64Counter(name)
+	if err == nil && c == nil {
+		err = errNilInstrument
+	}
+	if err != nil {
+		otel.Handle(fmt.Errorf("%s counter: %w", name, err))
+		return metricnoop.Int64Counter{}
 	}
+	return c
+}
 
-	l.logCounter.Add(l.ctx, 1, metric.WithAttributes(attribute.String("level", level)))
-	l.durationHist.Record(l.ctx, durationMs, metric.WithAttributes(attribute.String("level", level)))
+func int64Histogram(m metric.Meter, name string) metric.Int64Histogram {
+	h, err := m.Int64Histogram(name)
+	if err == nil && h == nil {
+		err = errNilInstrument
+	}
+	if err != nil {
+		otel.Handle(fmt.Errorf("%s histogram: %w", name, err))
+		return metricnoop.Int64Histogram{}
+	}
+	return h
 }
 
-func initMetrics(m metric.Meter) (metric.Int64Counter, metric.Float64Histogram) {
-	c, _ := m.Int64Counter("log_total")
-	h, _ := m.Float64Histogram("log_duration_ms")
-	return c, h
+func float64Histogram(m metric.Meter, name string) metric.Float64Histogram {
+	h, err := m.Float64Histogram(name)
+	if err == nil && h == nil {
+		err = errNilInstrument
+	}
+	if err != nil {
+		otel.Handle(fmt.Errorf("%s histogram: %w", name, err))
+		return metricnoop.Float64Histogram{}
+	}
+	return h
 }
 
-type defaultExporter struct{}
+// defaultExporter sends to Loki and Sentry. Captured errors carry the
+// breadcrumbs of the logger that created it.
+type defaultExporter struct {
+	crumbs *breadcrumbs
+}
 
 func (d defaultExporter) Send(level string, msg string, traceID string, spanID string) {
-	logChan <- LokiEntry{
-		Labels: map[string]string{
-			"level":    level,
-			"job":      globalCfg.JobName,
-			"service":  globalCfg.ServiceName,
-			"trace_id": traceID,
-			"span_id":  spanID,
-		},
-		Message: msg,
-	}
+	d.SendEntry(newLokiEntry(level, msg, traceID, spanID, nil, nil))
+}
+
+func (d defaultExporter) SendEntry(entry LokiEntry) {
+	queueLoki(entry)
 }
 
 func (d defaultExporter) CaptureError(err error, tags map[string]string, extras map[string]any) {
-	CaptureError(err, tags, extras)
+	captureError(err, tags, extras, d.crumbs.list())
+}
+
+func (d defaultExporter) CaptureMessage(level, msg string, tags map[string]string) {
+	captureMessage(level, msg, tags, d.crumbs.list())
 }
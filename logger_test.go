@@ -3,11 +3,145 @@ package eotel
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(tracenoop.NewTracerProvider()) })
+	return sr
+}
+
+func newMetricReader(t *testing.T) *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(metricnoop.NewMeterProvider()) })
+	return reader
+}
+
+// counterValue returns the sum of all data points of the named int64 counter.
+func counterValue(t *testing.T, reader *sdkmetric.ManualReader, name string) int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, dp := range sum.DataPoints {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+// histogramCount returns the number of observations of the named histogram.
+func histogramCount(t *testing.T, reader *sdkmetric.ManualReader, name string) uint64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var count uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			switch h := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, dp := range h.DataPoints {
+					count += dp.Count
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range h.DataPoints {
+					count += dp.Count
+				}
+			}
+		}
+	}
+	return count
+}
+
+func newObservedLogger(name string) (*Eotel, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := New(context.Background(), name).(*Eotel)
+	l.logger = zap.New(core)
+	return l, logs
+}
+
+type sentLog struct {
+	level, msg, traceID, spanID string
+}
+
+type spyExporter struct {
+	mu       sync.Mutex
+	sent     []sentLog
+	captured []error
+	messages []sentLog
+}
+
+func (s *spyExporter) Send(level string, msg string, traceID string, spanID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, sentLog{level: level, msg: msg, traceID: traceID, spanID: spanID})
+}
+
+func (s *spyExporter) CaptureError(err error, tags map[string]string, extras map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captured = append(s.captured, err)
+}
+
+func (s *spyExporter) CaptureMessage(level, msg string, tags map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, sentLog{level: level, msg: msg, traceID: tags["trace_id"], spanID: tags["span_id"]})
+}
+
+func (s *spyExporter) Messages() []sentLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sentLog(nil), s.messages...)
+}
+
+func (s *spyExporter) Sent() []sentLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sentLog(nil), s.sent...)
+}
+
+func (s *spyExporter) Captured() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.captured...)
+}
+
 func TestLoggerCreation(t *testing.T) {
 	cfg := Config{
 		ServiceName:   "test-service",
@@ -19,6 +153,7 @@ func TestLoggerCreation(t *testing.T) {
 	}
 	_, err := InitEOTEL(context.Background(), cfg)
 	assert.NoError(t, err)
+	t.Cleanup(func() { _ = Shutdown(context.Background()) })
 
 	logger := New(context.Background(), "TestLogger")
 	assert.NotNil(t, logger)
@@ -29,8 +164,872 @@ func TestLoggerError(t *testing.T) {
 	logger := New(context.Background(), "TestLogger")
 
 	mockErr := errors.New("mock error")
-	logger.WithError(mockErr).Error("error occurred")
+	logger = logger.WithError(mockErr)
+	logger.Error("error occurred")
 
 	// ตรวจสอบว่า error ถูกเซ็ตใน logger
-	assert.EqualError(t, logger.err, "mock error")
+	assert.EqualError(t, logger.(*Eotel).err, "mock error")
+}
+
+func TestWithFieldConcurrentChildren(t *testing.T) {
+	parent := New(context.Background(), "TestLogger").WithField("parent", true)
+
+	children := make([]Logger, 100)
+	var wg sync.WaitGroup
+	for i := range children {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			children[i] = parent.WithField("child", i)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, parent.(*Eotel).fields, 1)
+	for i, child := range children {
+		fields := child.(*Eotel).fields
+		if assert.Len(t, fields, 2) {
+			assert.Equal(t, "parent", fields[0].Key)
+			assert.Equal(t, "child", fields[1].Key)
+			assert.Equal(t, int64(i), fields[1].Integer)
+		}
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	newSpanRecorder(t)
+	spy := &spyExporter{}
+	original := New(context.Background(), "TestLogger", WithExporter(spy)).Child("op").WithField("order_id", 1).(*Eotel)
+
+	clone := original.Clone().(*Eotel)
+	clone.addField("tenant", "acme")
+	clone.fields[0] = zap.Int("order_id", 2)
+
+	assert.Len(t, original.fields, 1)
+	assert.Len(t, original.attrs, 1)
+	assert.Equal(t, int64(1), original.fields[0].Integer)
+	assert.Len(t, clone.fields, 2)
+	assert.Same(t, spy, clone.exporter)
+	assert.Equal(t, original.span.SpanContext(), clone.span.SpanContext())
+	assert.Equal(t, original.Ctx(), clone.Ctx())
+}
+
+func TestInfoCtxLogsUnderContextSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger := New(context.Background(), "TestLogger")
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "handler")
+	logger.InfoCtx(ctx, "inside handler")
+	span.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "handler", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("log.message", "inside handler"))
+}
+
+func TestInfoStartsOwnSpanWithoutContextSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+	New(context.Background(), "TestLogger").Info("standalone")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "TestLogger", spans[0].Name())
+}
+
+func TestErrorfFormatsMessage(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger, logs := newObservedLogger("TestLogger")
+
+	logger.Errorf("failed %d times", 3)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "failed 3 times", entries[0].Message)
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("log.message", "failed 3 times"))
+}
+
+func TestFatalFlushesLokiBeforeExit(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+
+	var deliveredAtExit []string
+	t.Cleanup(setExitFunc(func(int) { deliveredAtExit = loki.Messages() }))
+
+	logger := New(context.Background(), "TestLogger")
+	for i := 0; i < 10; i++ {
+		logger.Infof("queued %d", i)
+	}
+	logger.Fatal("going down")
+
+	require.Len(t, deliveredAtExit, 11)
+	assert.Equal(t, "going down", deliveredAtExit[10])
+}
+
+func TestFatalExitsWithCodeOneAfterFlush(t *testing.T) {
+	sr := newSpanRecorder(t)
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+	logger, logs := newObservedLogger("TestLogger")
+
+	exitCode := -1
+	var logged, spansEnded, delivered int
+	t.Cleanup(setExitFunc(func(code int) {
+		exitCode = code
+		logged = logs.FilterLevelExact(zapcore.FatalLevel).Len()
+		spansEnded = len(sr.Ended())
+		delivered = len(loki.Messages())
+	}))
+
+	logger.Fatal("fatal error")
+
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, 1, logged)
+	assert.Equal(t, 1, spansEnded)
+	assert.Equal(t, 1, delivered)
+}
+
+func TestFatalLeavesTelemetryRunning(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+	t.Cleanup(setExitFunc(func(int) {}))
+
+	logger := New(context.Background(), "TestLogger")
+	logger.Fatal("going down")
+	logger.Info("still here")
+	require.NoError(t, Flush(context.Background()))
+
+	assert.Equal(t, []string{"going down", "still here"}, loki.Messages())
+}
+
+func TestWithExporterReceivesLogsAndErrors(t *testing.T) {
+	sr := newSpanRecorder(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true})
+	spy := &spyExporter{}
+
+	logger := New(context.Background(), "TestLogger", WithExporter(spy))
+	mockErr := errors.New("mock error")
+	logger.WithError(mockErr).Error("failed")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	sent := spy.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "error", sent[0].level)
+	assert.Equal(t, "failed", sent[0].msg)
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), sent[0].traceID)
+	assert.Equal(t, []error{mockErr}, spy.Captured())
+	assert.Same(t, spy, logger.Child("child").(*Eotel).exporter)
+}
+
+func TestTypedFieldsMatchWithField(t *testing.T) {
+	sr := newSpanRecorder(t)
+	l, logs := newObservedLogger("typed")
+
+	l.WithStr("user", "bob").
+		WithInt("attempt", 3).
+		WithFloat("ratio", 0.5).
+		WithBool("retry", true).
+		WithDuration("elapsed", 1500*time.Millisecond).
+		Info("typed")
+	l.WithFields(map[string]any{
+		"user": "bob", "attempt": 3, "ratio": 0.5, "retry": true, "elapsed": 1500 * time.Millisecond,
+	}).Info("generic")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	typed, generic := entries[0].ContextMap(), entries[1].ContextMap()
+	for _, id := range []string{"trace_id", "span_id"} {
+		delete(typed, id)
+		delete(generic, id)
+	}
+	assert.Equal(t, generic, typed)
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	for _, kv := range []attribute.KeyValue{
+		attribute.String("user", "bob"),
+		attribute.Int("attempt", 3),
+		attribute.Float64("ratio", 0.5),
+		attribute.Bool("retry", true),
+		attribute.String("elapsed", "1.5s"),
+	} {
+		assert.Contains(t, spans[0].Attributes(), kv)
+		assert.Contains(t, spans[1].Attributes(), kv)
+	}
+}
+
+func BenchmarkWithField(b *testing.B) {
+	l := New(context.Background(), "BenchLogger")
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		l.WithField("order_id", 1000+i).WithField("ratio", float64(i)/2)
+	}
+}
+
+func BenchmarkWithTyped(b *testing.B) {
+	l := New(context.Background(), "BenchLogger")
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		l.WithInt("order_id", 1000+i).WithFloat("ratio", float64(i)/2)
+	}
+}
+
+func TestWithFieldsIsDeterministic(t *testing.T) {
+	fields := map[string]any{"zeta": 1, "alpha": 2, "mid": 3, "beta": 4, "omega": 5}
+	want := []string{"alpha", "beta", "mid", "omega", "zeta"}
+
+	for i := 0; i < 20; i++ {
+		l := New(context.Background(), "TestLogger").WithFields(fields).(*Eotel)
+		var keys, attrKeys []string
+		for _, f := range l.fields {
+			keys = append(keys, f.Key)
+		}
+		for _, a := range l.attrs {
+			attrKeys = append(attrKeys, string(a.Key))
+		}
+		assert.Equal(t, want, keys)
+		assert.Equal(t, want, attrKeys)
+	}
+}
+
+func TestWithErrorCapturesOnlyAtErrorLevel(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+	spy := &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(spy))
+	mockErr := errors.New("mock error")
+
+	logger.WithError(mockErr).Info("just context")
+	logger.WithError(mockErr).Warn("still not an error")
+	assert.Empty(t, spy.Captured())
+
+	logger.WithError(mockErr).Error("real failure")
+	assert.Equal(t, []error{mockErr}, spy.Captured())
+}
+
+func TestSentryCaptureLevelIsConfigurable(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", SentryCaptureLevel: "warn"})
+	spy := &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(spy))
+
+	logger.WithError(errors.New("mock error")).Warn("degraded")
+	assert.Len(t, spy.Captured(), 1)
+}
+
+func TestCaptureMessageRoutesThroughExporter(t *testing.T) {
+	newSpanRecorder(t)
+	spy := &spyExporter{}
+	logger := New(context.Background(), "TestLogger", WithExporter(spy)).Child("work")
+
+	logger.CaptureMessage("warn", "quota almost exhausted")
+
+	msgs := spy.Messages()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "warn", msgs[0].level)
+	assert.Equal(t, "quota almost exhausted", msgs[0].msg)
+	assert.Equal(t, logger.TraceID(), msgs[0].traceID)
+	assert.Empty(t, spy.Captured())
+}
+
+func TestTraceIDMatchesLoggedField(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger, logs := newObservedLogger("TestLogger")
+
+	traceID, spanID := logger.TraceID(), logger.SpanID()
+	require.NotEmpty(t, traceID)
+	logger.Info("with ids")
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, traceID, entries[0].ContextMap()["trace_id"])
+	assert.Equal(t, spanID, entries[0].ContextMap()["span_id"])
+	require.Len(t, sr.Ended(), 1)
+
+	child := logger.Child("child")
+	assert.Equal(t, traceID, child.TraceID())
+	assert.NotEqual(t, spanID, child.SpanID())
+}
+
+func TestTraceIDEmptyWithoutTracing(t *testing.T) {
+	logger := New(context.Background(), "TestLogger")
+	assert.Empty(t, logger.TraceID())
+	assert.Empty(t, logger.SpanID())
+}
+
+func TestChildInheritsParentContext(t *testing.T) {
+	sr := newSpanRecorder(t)
+	parent, logs := newObservedLogger("TestLogger")
+	withErr := parent.WithField("request_id", "req-1").WithError(errors.New("parent failure"))
+
+	child := withErr.Child("child-op").(*Eotel)
+	assert.Equal(t, "child-op", child.name)
+	assert.Nil(t, child.err)
+	assert.NotPanics(t, func() { child.Info("from child") })
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.NotContains(t, fields, "error")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "child-op", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("request_id", "req-1"))
+	assert.Len(t, withErr.(*Eotel).fields, 2)
+}
+
+func TestChildKindSetsSpanKind(t *testing.T) {
+	sr := newSpanRecorder(t)
+	parent, _ := newObservedLogger("TestLogger")
+
+	producer := parent.ChildKind("orders publish", trace.SpanKindProducer)
+	producer.Info("published")
+	parent.Child("internal").Info("plain")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "orders publish", spans[0].Name())
+	assert.Equal(t, trace.SpanKindProducer, spans[0].SpanKind())
+	assert.Equal(t, trace.SpanKindInternal, spans[1].SpanKind())
+}
+
+func TestStartRootHasNoParent(t *testing.T) {
+	sr := newSpanRecorder(t)
+	ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+	defer request.End()
+	parent := New(ctx, "handler").WithField("order_id", 7)
+
+	parent.StartRoot("send receipt").Info("sent")
+	parent.Child("charge").Info("charged")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	job, child := spans[0], spans[1]
+	assert.Equal(t, "send receipt", job.Name())
+	assert.False(t, job.Parent().IsValid())
+	assert.NotEqual(t, request.SpanContext().TraceID(), job.SpanContext().TraceID())
+	assert.Contains(t, job.Attributes(), attribute.Int("order_id", 7))
+	assert.Equal(t, request.SpanContext().SpanID(), child.Parent().SpanID())
+}
+
+func TestWithSpanRecordsReturnedError(t *testing.T) {
+	sr := newSpanRecorder(t)
+	parent, logs := newObservedLogger("TestLogger")
+	errLookup := errors.New("lookup failed")
+
+	err := parent.WithSpan("load order", func(l Logger) error {
+		l.Info("loading")
+		return errLookup
+	})
+	require.ErrorIs(t, err, errLookup)
+	require.NoError(t, parent.WithSpan("ok", func(Logger) error { return nil }))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	failed := spans[0]
+	assert.Equal(t, "load order", failed.Name())
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, "lookup failed", failed.Status().Description)
+	require.NotEmpty(t, failed.Events())
+	assert.Equal(t, "exception", failed.Events()[len(failed.Events())-1].Name)
+	assert.Contains(t, failed.Attributes(), attribute.String("log.message", "loading"))
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, failed.SpanContext().SpanID().String(), entries[0].ContextMap()["span_id"])
+}
+
+func TestWithBaggageReachesChildSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger, logs := newObservedLogger("TestLogger")
+
+	logger.WithBaggage("tenant_id", "acme").Child("downstream").Info("handled")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "downstream", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("tenant_id", "acme"))
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "acme", entries[0].ContextMap()["tenant_id"])
+}
+
+func TestFromContextOKFindsInjectedLogger(t *testing.T) {
+	logger, logs := newObservedLogger("TestLogger")
+	ctx := logger.Inject(context.Background(), logger)
+
+	got, ok := logger.FromContextOK(ctx, "handler")
+	assert.True(t, ok)
+	assert.Equal(t, logger, got)
+	assert.NotSame(t, logger, got, "FromContextOK should return a clone")
+	assert.NotSame(t, logger, logger.FromContext(ctx, "handler"))
+
+	// Fields added to the returned logger stay out of the injected one.
+	got.WithField("user_id", 7).Info("from the clone")
+	logger.Info("from the injected logger")
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(7), entries[0].ContextMap()["user_id"])
+	assert.NotContains(t, entries[1].ContextMap(), "user_id")
+}
+
+func TestFromContextFallbackAdoptsContextSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+
+	got, ok := New(context.Background(), "TestLogger").FromContextOK(ctx, "handler")
+	require.False(t, ok)
+	assert.Equal(t, span.SpanContext().TraceID().String(), got.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID().String(), got.SpanID())
+	got.Info("fallback log")
+	span.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "request", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("log.message", "fallback log"))
+}
+
+type wrappedLogger struct {
+	Logger
+}
+
+func TestFromContextReturnsAnyLoggerImplementation(t *testing.T) {
+	base := New(context.Background(), "TestLogger")
+	for _, injected := range []Logger{NewNop(), wrappedLogger{Logger: base}} {
+		ctx := base.Inject(context.Background(), injected)
+
+		got, ok := base.FromContextOK(ctx, "handler")
+		assert.True(t, ok)
+		assert.Equal(t, injected, got)
+		assert.Equal(t, injected, base.FromContext(ctx, "handler"))
+	}
+}
+
+func TestPackageLevelContextHelpers(t *testing.T) {
+	logger := New(context.Background(), "TestLogger")
+	ctx := Inject(context.Background(), logger)
+
+	assert.Equal(t, logger, FromContext(ctx, "handler"))
+	got, ok := FromContextOK(context.Background(), "handler")
+	assert.False(t, ok)
+	assert.NotNil(t, got)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	InjectToGin(c, logger)
+	assert.Equal(t, logger, FromGin(c, "handler"))
+}
+
+func TestEndClosesSpanWithoutLogging(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+	logger := New(context.Background(), "TestLogger").Child("unlogged")
+
+	logger.SetSpanAttr("key", "value")
+	require.Empty(t, sr.Ended())
+
+	logger.End()
+	logger.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "unlogged", spans[0].Name())
+	assert.Equal(t, uint64(1), histogramCount(t, reader, "logger_lifetime_ms"))
+	assert.Equal(t, uint64(0), histogramCount(t, reader, "log_duration_ms"))
+}
+
+func TestLoggerAdoptsContextSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+
+	logger := New(ctx, "TestLogger")
+	logger.Info("adopted")
+	logger.End()
+	require.Empty(t, sr.Ended())
+
+	span.End()
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "request", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("log.message", "adopted"))
+}
+
+func TestFieldAttributesKeepTypes(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger := New(context.Background(), "TestLogger").
+		WithField("count", 3).
+		WithField("ratio", 0.5).
+		WithField("cached", true).
+		WithField("tags", []string{"a", "b"}).
+		WithField("timeout", 2*time.Second).
+		WithField("point", struct{ X int }{1}).
+		Child("typed")
+	logger.SetSpanAttr("attempt", int64(2))
+	logger.Info("typed")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	attrs := spans[0].Attributes()
+	assert.Contains(t, attrs, attribute.Int("count", 3))
+	assert.Contains(t, attrs, attribute.Float64("ratio", 0.5))
+	assert.Contains(t, attrs, attribute.Bool("cached", true))
+	assert.Contains(t, attrs, attribute.StringSlice("tags", []string{"a", "b"}))
+	assert.Contains(t, attrs, attribute.String("timeout", "2s"))
+	assert.Contains(t, attrs, attribute.String("point", "{1}"))
+	assert.Contains(t, attrs, attribute.Int64("attempt", 2))
+}
+
+func setCaptureStack(t *testing.T, enabled bool) {
+	prev := globalCfg.CaptureStack
+	globalCfg.CaptureStack = enabled
+	t.Cleanup(func() { globalCfg.CaptureStack = prev })
+}
+
+func stackField(t *testing.T, logs *observer.ObservedLogs) (string, bool) {
+	t.Helper()
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	stack, ok := entries[0].ContextMap()["stacktrace"].(string)
+	return stack, ok
+}
+
+func TestWithErrorStackTrace(t *testing.T) {
+	setCaptureStack(t, true)
+	sr := newSpanRecorder(t)
+	logger, logs := newObservedLogger("TestLogger")
+
+	logger.WithError(errors.New("boom")).Error("failed")
+
+	stack, ok := stackField(t, logs)
+	require.True(t, ok)
+	assert.Contains(t, stack, "TestWithErrorStackTrace")
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("stacktrace", stack))
+}
+
+func TestWithErrorStackTraceOnlyForErrorLevels(t *testing.T) {
+	setCaptureStack(t, true)
+	logger, logs := newObservedLogger("TestLogger")
+
+	logger.WithError(errors.New("boom")).Warn("degraded")
+
+	_, ok := stackField(t, logs)
+	assert.False(t, ok)
+}
+
+func TestWithErrorStackTraceDisabled(t *testing.T) {
+	setCaptureStack(t, false)
+	logger, logs := newObservedLogger("TestLogger")
+
+	logger.WithError(errors.New("boom")).Error("failed")
+
+	_, ok := stackField(t, logs)
+	assert.False(t, ok)
+}
+
+func TestWithStackOverridesCaptureStack(t *testing.T) {
+	setCaptureStack(t, false)
+	logger, logs := newObservedLogger("TestLogger")
+	logger.WithStack().WithError(errors.New("boom")).Error("failed")
+	stack, ok := stackField(t, logs)
+	require.True(t, ok)
+	assert.Contains(t, stack, "TestWithStackOverridesCaptureStack")
+
+	logger, logs = newObservedLogger("TestLogger")
+	logger.WithError(errors.New("boom")).WithStack().Error("failed")
+	_, ok = stackField(t, logs)
+	assert.True(t, ok, "WithStack after WithError")
+
+	setCaptureStack(t, true)
+	logger, logs = newObservedLogger("TestLogger")
+	logger.WithoutStack().WithError(errors.New("boom")).Error("failed")
+	_, ok = stackField(t, logs)
+	assert.False(t, ok)
+
+	logger, logs = newObservedLogger("TestLogger")
+	logger.WithError(errors.New("boom")).WithoutStack().Error("failed")
+	_, ok = stackField(t, logs)
+	assert.False(t, ok, "WithoutStack after WithError")
+}
+
+// stackError mimics github.com/pkg/errors, whose errors expose the stack they
+// were created at through StackTrace().
+type stackError struct {
+	msg   string
+	stack []uintptr
+}
+
+type stackFrames []uintptr
+
+func (e *stackError) Error() string           { return e.msg }
+func (e *stackError) StackTrace() stackFrames { return stackFrames(e.stack) }
+
+func newStackError(msg string) error {
+	return &stackError{msg: msg, stack: callers(0)}
+}
+
+func TestWithErrorUsesRecordedStack(t *testing.T) {
+	setCaptureStack(t, true)
+	logger, logs := newObservedLogger("TestLogger")
+
+	err := fmt.Errorf("wrapped: %w", newStackError("boom"))
+	logger.WithError(err).Error("failed")
+
+	stack, ok := stackField(t, logs)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(stack, "github.com/nicedev97/eotel.newStackError"), stack)
+}
+
+type notFoundError struct{ id string }
+
+func (e *notFoundError) Error() string { return "not found: " + e.id }
+
+func TestWithErrorRecordsCauseChain(t *testing.T) {
+	sr := newSpanRecorder(t)
+	root := &notFoundError{id: "42"}
+	mid := fmt.Errorf("load order: %w", root)
+	top := fmt.Errorf("handle request: %w", mid)
+
+	New(context.Background(), "TestLogger").WithError(top).Error("failed")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 3)
+	assert.Equal(t, "exception", events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.String("exception.message", top.Error()))
+	assert.Equal(t, "error.cause", events[1].Name)
+	assert.Contains(t, events[1].Attributes, attribute.String("error.message", mid.Error()))
+	assert.Contains(t, events[1].Attributes, attribute.String("error.type", "*fmt.wrapError"))
+	assert.Equal(t, "error.cause", events[2].Name)
+	assert.Contains(t, events[2].Attributes, attribute.String("error.type", "*eotel.notFoundError"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error.type", "*eotel.notFoundError"))
+}
+
+func TestSetSpanErrorRecordsCauseChain(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger := New(context.Background(), "TestLogger").Child("op")
+
+	logger.SetSpanError(fmt.Errorf("op: %w", &notFoundError{id: "7"}))
+	logger.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 2)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error.type", "*eotel.notFoundError"))
+}
+
+func TestRecordedErrorsMarkSpanFailed(t *testing.T) {
+	sr := newSpanRecorder(t)
+	logger := New(context.Background(), "TestLogger")
+
+	explicit := logger.Child("explicit")
+	explicit.SetSpanError(errors.New("boom"), attribute.String("order.id", "42"))
+	explicit.End()
+	logger.Child("logged").WithError(errors.New("failed")).Error("request failed")
+	logger.Child("warned").WithError(errors.New("retrying")).Warn("transient")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+	require.NotEmpty(t, spans[0].Events())
+	assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("order.id", "42"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "failed", spans[1].Status().Description)
+	assert.Equal(t, codes.Unset, spans[2].Status().Code)
+	assert.Len(t, spans[2].Events(), 1)
+}
+
+func TestErrorCounterCountsErrorAndFatalOnly(t *testing.T) {
+	reader := newMetricReader(t)
+	defer setExitFunc(func(int) {})()
+	logger := New(context.Background(), "TestLogger")
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	assert.Zero(t, counterValue(t, reader, "errors_total"))
+
+	logger.WithError(&notFoundError{id: "1"}).Error("error")
+	logger.Fatal("fatal")
+	assert.Equal(t, int64(2), counterValue(t, reader, "errors_total"))
+	// Debug is below the default level and never reaches the metrics.
+	assert.Equal(t, uint64(4), histogramCount(t, reader, "log_message_bytes"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var types []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "errors_total" {
+				for _, dp := range sum.DataPoints {
+					if v, ok := dp.Attributes.Value("error.type"); ok {
+						types = append(types, v.AsString())
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{"*eotel.notFoundError"}, types)
+}
+
+func TestLogAfterContextDoneIsFlagged(t *testing.T) {
+	prev := globalCfg.RecordContextErrors
+	globalCfg.RecordContextErrors = true
+	t.Cleanup(func() { globalCfg.RecordContextErrors = prev })
+	sr := newSpanRecorder(t)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	l, logs := newObservedLogger("handler")
+	l.WithContext(cancelled).Info("client went away")
+	l.WithContext(expired).Info("too slow")
+	l.WithContext(context.Background()).Info("all good")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	assert.Equal(t, true, entries[0].ContextMap()["context.cancelled"])
+	assert.Equal(t, true, entries[1].ContextMap()["context.deadline_exceeded"])
+	assert.NotContains(t, entries[2].ContextMap(), "context.cancelled")
+	assert.NotContains(t, entries[2].ContextMap(), "context.deadline_exceeded")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool("context.cancelled", true))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), attribute.Bool("context.deadline_exceeded", true))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, codes.Unset, spans[2].Status().Code)
+}
+
+func TestWithContextKeepsFieldsAndUsesNewSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	base, logs := newObservedLogger("handler")
+	l := base.WithField("user_id", "u-7").WithInt("attempt", 2).WithError(errors.New("boom"))
+	before := l.(*Eotel).clone()
+
+	ctx, other := Tracer().Start(context.Background(), "other")
+	l.WithContext(ctx).Warn("rebound")
+	other.End()
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "u-7", fields["user_id"])
+	assert.Equal(t, int64(2), fields["attempt"])
+	assert.Equal(t, "boom", fields["error"])
+	assert.Equal(t, other.SpanContext().TraceID().String(), fields["trace_id"])
+	assert.Equal(t, other.SpanContext().SpanID().String(), fields["span_id"])
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "other", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("user_id", "u-7"))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("attempt", 2))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error", "boom"))
+
+	// The original logger keeps its own context, span and fields.
+	orig := l.(*Eotel)
+	assert.Equal(t, before.span, orig.span)
+	assert.Equal(t, before.ctx, orig.ctx)
+	assert.Equal(t, before.fields, orig.fields)
+	assert.Equal(t, before.attrs, orig.attrs)
+	assert.NotEqual(t, other.SpanContext().SpanID(), trace.SpanContextFromContext(orig.Ctx()).SpanID())
+}
+
+func TestAttrsFieldsApplyToOneLineOnly(t *testing.T) {
+	sr := newSpanRecorder(t)
+	l, logs := newObservedLogger("worker")
+	logger := l.WithField("job_id", 9)
+
+	logger.InfoAttrs("processing", map[string]any{"attempt": 2})
+	logger.Child("next").Info("done")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(2), entries[0].ContextMap()["attempt"])
+	assert.Equal(t, int64(9), entries[0].ContextMap()["job_id"])
+	assert.NotContains(t, entries[1].ContextMap(), "attempt")
+	assert.Equal(t, int64(9), entries[1].ContextMap()["job_id"])
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("attempt", 2))
+	for _, kv := range spans[1].Attributes() {
+		assert.NotEqual(t, attribute.Key("attempt"), kv.Key)
+	}
+}
+
+// retainingCore keeps the field slices it is given as they are, the way a
+// core that queues entries for later encoding does. observer copies them.
+type retainingCore struct {
+	zapcore.LevelEnabler
+	mu      sync.Mutex
+	entries [][]zap.Field
+}
+
+func (c *retainingCore) With([]zap.Field) zapcore.Core { return c }
+func (c *retainingCore) Sync() error                   { return nil }
+
+func (c *retainingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *retainingCore) Write(_ zapcore.Entry, fields []zap.Field) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, fields)
+	return nil
+}
+
+func TestLogFieldsSurviveRetainingCore(t *testing.T) {
+	core := &retainingCore{LevelEnabler: zapcore.DebugLevel}
+	logger := New(context.Background(), "TestLogger", WithZapLogger(zap.New(core)))
+
+	logger.WithField("order_id", "o-1").Info("first")
+	for i := range 10 {
+		logger.WithField("other", i).Info("later")
+	}
+
+	require.Len(t, core.entries, 11)
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range core.entries[0] {
+		f.AddTo(enc)
+	}
+	assert.Equal(t, "o-1", enc.Fields["order_id"])
+	assert.NotContains(t, enc.Fields, "other")
+}
+
+func BenchmarkInfo(b *testing.B) {
+	l := New(context.Background(), "BenchLogger").WithField("order_id", 42).(*Eotel)
+	l.logger = zap.NewNop()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		l.Info("order shipped")
+	}
+}
+
+func TestWithZapLoggerPropagatesToChildren(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := New(context.Background(), "TestLogger", WithZapLogger(zap.New(core)))
+
+	logger.Info("parent line")
+	logger.Child("step").WithField("k", "v").Info("child line")
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "parent line", entries[0].Message)
+	assert.Equal(t, "child line", entries[1].Message)
+	assert.Equal(t, "v", entries[1].ContextMap()["k"])
 }
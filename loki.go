@@ -2,48 +2,553 @@ package eotel
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math/rand/v2"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// lokiReservedLabels are set on every entry by the default exporter and take
+// precedence over LokiLabels.
+var lokiReservedLabels = []string{"level", "job", "service", "trace_id", "span_id"}
+
+// lokiMetadata renders fields as Loki structured metadata, or returns nil when
+// LokiStructuredMetadata is off.
+func lokiMetadata(fields []zap.Field) map[string]string {
+	if !globalCfg.LokiStructuredMetadata || len(fields) == 0 {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	metadata := make(map[string]string, len(enc.Fields))
+	for k, v := range enc.Fields {
+		if s, ok := v.(string); ok {
+			metadata[k] = s
+			continue
+		}
+		metadata[k] = fmt.Sprint(v)
+	}
+	return metadata
+}
+
+// maxLokiLabels caps the labels a logger can add with WithLabel, to keep
+// stream cardinality in check.
+const maxLokiLabels = 5
+
+// newLokiEntry builds the entry for a log line. Labels layer up from
+// LokiLabels, then the logger's own labels, then the reserved ones.
+func newLokiEntry(level, msg, traceID, spanID string, labels, metadata map[string]string) LokiEntry {
+	all := make(map[string]string, len(globalCfg.LokiLabels)+len(labels)+len(lokiReservedLabels))
+	maps.Copy(all, globalCfg.LokiLabels)
+	maps.Copy(all, labels)
+	all["level"] = level
+	all["job"] = globalCfg.JobName
+	all["service"] = globalCfg.ServiceName
+	all["trace_id"] = traceID
+	all["span_id"] = spanID
+	return LokiEntry{Labels: all, Message: msg, Timestamp: time.Now(), Metadata: metadata}
+}
+
 type LokiEntry struct {
 	Labels  map[string]string
 	Message string
+	// Timestamp is when the line was logged; zero means the time it is
+	// pushed.
+	Timestamp time.Time
+	// Metadata is sent as Loki structured metadata (Loki 2.9+): queryable
+	// per-line key/values that, unlike labels, do not create streams.
+	Metadata map[string]string
+}
+
+// lokiSender owns the goroutine that batches queued entries and pushes them
+// to Loki.
+type lokiSender struct {
+	entries chan LokiEntry
+	flushes chan lokiFlush
+	cancel  context.CancelFunc
+	done    chan struct{}
+	// pushCtx bounds the pushes made outside a Flush; abort cancels it
+	// when Shutdown gives up waiting, so retries stop with it.
+	pushCtx context.Context
+	abort   context.CancelFunc
+}
+
+// lokiFlush asks the sender to push everything queued, within ctx, and to
+// close done when it has.
+type lokiFlush struct {
+	ctx  context.Context
+	done chan struct{}
+}
+
+var (
+	lokiMu     sync.Mutex
+	activeLoki *lokiSender
+)
+
+// startLoki starts a sender, stopping any previous one. The sender exits once
+// ctx is done, pushing whatever is still queued first.
+func startLoki(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	pushCtx, abort := context.WithCancel(context.Background())
+	s := &lokiSender{
+		entries: make(chan LokiEntry, lokiQueueSize()),
+		flushes: make(chan lokiFlush),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		pushCtx: pushCtx,
+		abort:   abort,
+	}
+	lokiMu.Lock()
+	prev := activeLoki
+	activeLoki = s
+	lokiMu.Unlock()
+	if prev != nil {
+		prev.stop()
+	}
+	go s.run(ctx)
+}
+
+func currentLoki() *lokiSender {
+	lokiMu.Lock()
+	defer lokiMu.Unlock()
+	return activeLoki
+}
+
+const (
+	lokiOverflowDrop    = "drop"
+	lokiOverflowBlock   = "block"
+	lokiOverflowTimeout = "timeout"
+)
+
+// queueLoki hands entry to the running sender. Entries are dropped when no
+// sender is running. When the queue is full, LokiOverflowPolicy decides
+// whether to drop the entry (the default), wait for room, or wait at most
+// LokiOverflowTimeout; drops are counted in loki_dropped_total.
+func queueLoki(entry LokiEntry) {
+	s := currentLoki()
+	if s == nil {
+		return
+	}
+	switch globalCfg.LokiOverflowPolicy {
+	case lokiOverflowBlock:
+		select {
+		case s.entries <- entry:
+		case <-s.done:
+		}
+	case lokiOverflowTimeout:
+		timer := time.NewTimer(lokiOverflowWait())
+		defer timer.Stop()
+		select {
+		case s.entries <- entry:
+		case <-s.done:
+		case <-timer.C:
+			recordLokiDropped(1)
+		}
+	default:
+		select {
+		case s.entries <- entry:
+		default:
+			recordLokiDropped(1)
+		}
+	}
+}
+
+func lokiOverflowWait() time.Duration {
+	if globalCfg.LokiOverflowTimeout > 0 {
+		return globalCfg.LokiOverflowTimeout
+	}
+	return 100 * time.Millisecond
+}
+
+func (s *lokiSender) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.abort()
+	var batch lokiBatch
+	for {
+		select {
+		case entry := <-s.entries:
+			batch.add(s.pushCtx, entry)
+		case <-batch.expired():
+			batch.flush(s.pushCtx)
+		case f := <-s.flushes:
+			s.drain(f.ctx, &batch)
+			batch.flush(f.ctx)
+			close(f.done)
+		case <-ctx.Done():
+			s.drain(s.pushCtx, &batch)
+			batch.flush(s.pushCtx)
+			return
+		}
+	}
+}
+
+func (s *lokiSender) drain(ctx context.Context, batch *lokiBatch) {
+	for {
+		select {
+		case entry := <-s.entries:
+			batch.add(ctx, entry)
+		default:
+			return
+		}
+	}
+}
+
+func (s *lokiSender) stop() {
+	s.cancel()
+	<-s.done
+}
+
+// stopLoki stops the running sender, waiting until it has pushed what is
+// still queued or ctx is done. In the latter case the push in flight is
+// abandoned and the rest of the queue dropped.
+func stopLoki(ctx context.Context) error {
+	lokiMu.Lock()
+	s := activeLoki
+	activeLoki = nil
+	lokiMu.Unlock()
+	if s == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		// Pushes honour the aborted context, so the sender exits promptly.
+		s.abort()
+		<-s.done
+		return ctx.Err()
+	}
+}
+
+// flushLoki blocks until every entry queued before the call has been pushed,
+// or until ctx is done, which also stops the push from retrying.
+func flushLoki(ctx context.Context) error {
+	s := currentLoki()
+	if s == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	select {
+	case s.flushes <- lokiFlush{ctx: ctx, done: done}:
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lokiBatch accumulates entries until LokiBatchSize is reached or
+// LokiFlushInterval has passed since the first entry was added.
+type lokiBatch struct {
+	entries []LokiEntry
+	timer   *time.Timer
+}
+
+func (b *lokiBatch) add(ctx context.Context, entry LokiEntry) {
+	if len(b.entries) == 0 {
+		b.timer = time.NewTimer(lokiFlushInterval())
+	}
+	b.entries = append(b.entries, entry)
+	if len(b.entries) >= lokiBatchSize() {
+		b.flush(ctx)
+	}
+}
+
+func (b *lokiBatch) flush(ctx context.Context) {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.entries) == 0 {
+		return
+	}
+	pushLoki(ctx, b.entries)
+	b.entries = nil
+}
+
+func (b *lokiBatch) expired() <-chan time.Time {
+	if b.timer == nil {
+		return nil
+	}
+	return b.timer.C
+}
+
+func lokiBatchSize() int {
+	if globalCfg.LokiBatchSize > 0 {
+		return globalCfg.LokiBatchSize
+	}
+	return 100
 }
 
-var logChan = make(chan LokiEntry, 100)
+// lokiQueueSize is how many entries can wait for the sender: ten batches.
+func lokiQueueSize() int {
+	return 10 * lokiBatchSize()
+}
+
+func lokiFlushInterval() time.Duration {
+	if globalCfg.LokiFlushInterval > 0 {
+		return globalCfg.LokiFlushInterval
+	}
+	return time.Second
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values []lokiValue       `json:"values"`
+}
+
+// lokiValue is one line of a stream, encoded as [timestamp, line] or, when
+// it has structured metadata, [timestamp, line, metadata].
+type lokiValue struct {
+	Timestamp string
+	Line      string
+	Metadata  map[string]string
+}
+
+func (v lokiValue) MarshalJSON() ([]byte, error) {
+	if len(v.Metadata) == 0 {
+		return json.Marshal([2]string{v.Timestamp, v.Line})
+	}
+	return json.Marshal([]any{v.Timestamp, v.Line, v.Metadata})
+}
 
-func init() {
-	go func() {
-		for entry := range logChan {
-			_ = sendLoki(entry)
+func (v *lokiValue) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 2 || len(raw) > 3 {
+		return fmt.Errorf("loki value: want 2 or 3 elements, got %d", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &v.Timestamp); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &v.Line); err != nil {
+		return err
+	}
+	if len(raw) == 3 {
+		return json.Unmarshal(raw[2], &v.Metadata)
+	}
+	return nil
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// buildLokiPayload groups entries with identical label sets into streams,
+// each ordered by timestamp as Loki expects.
+func buildLokiPayload(entries []LokiEntry) ([]byte, error) {
+	now := time.Now()
+	var push lokiPush
+	streams := map[string]*lokiStream{}
+	times := map[*lokiStream][]time.Time{}
+	for _, entry := range entries {
+		key := labelsKey(entry.Labels)
+		stream, ok := streams[key]
+		if !ok {
+			stream = &lokiStream{Stream: entry.Labels}
+			streams[key] = stream
+			push.Streams = append(push.Streams, stream)
+		}
+		ts := entry.Timestamp
+		if ts.IsZero() {
+			ts = now
 		}
-	}()
+		stream.Values = append(stream.Values, lokiValue{
+			Timestamp: strconv.FormatInt(ts.UnixNano(), 10),
+			Line:      entry.Message,
+			Metadata:  entry.Metadata,
+		})
+		times[stream] = append(times[stream], ts)
+	}
+	for _, stream := range push.Streams {
+		sort.Stable(byTime{stream.Values, times[stream]})
+	}
+	return json.Marshal(push)
+}
+
+// byTime sorts stream values by their entries' timestamps.
+type byTime struct {
+	values []lokiValue
+	times  []time.Time
+}
+
+func (b byTime) Len() int           { return len(b.values) }
+func (b byTime) Less(i, j int) bool { return b.times[i].Before(b.times[j]) }
+func (b byTime) Swap(i, j int) {
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+	b.times[i], b.times[j] = b.times[j], b.times[i]
+}
+
+// pushLoki sends entries, retrying transient failures with exponential
+// backoff and jitter until ctx is done. Entries that still cannot be
+// delivered are dropped and counted in loki_dropped_total.
+func pushLoki(ctx context.Context, entries []LokiEntry) {
+	for attempt := 0; ; attempt++ {
+		err := sendLoki(ctx, entries)
+		if err == nil {
+			recordExport("loki", nil)
+			return
+		}
+		if attempt >= lokiMaxRetries() || !retryableLoki(err) || !sleepCtx(ctx, lokiRetryDelay(attempt)) {
+			recordExport("loki", err)
+			break
+		}
+	}
+	recordLokiDropped(len(entries))
+}
+
+func lokiMaxRetries() int {
+	switch {
+	case globalCfg.LokiMaxRetries < 0:
+		return 0
+	case globalCfg.LokiMaxRetries == 0:
+		return 3
+	}
+	return globalCfg.LokiMaxRetries
 }
 
-func sendLoki(entry LokiEntry) error {
+func lokiRetryDelay(attempt int) time.Duration {
+	base := globalCfg.LokiRetryBaseDelay
+	if base <= 0 {
+		base = 200 * time.Millisecond
+	}
+	d := base << attempt
+	return d/2 + rand.N(d/2+1)
+}
+
+type lokiStatusError struct {
+	status string
+	code   int
+}
+
+func (e *lokiStatusError) Error() string {
+	return fmt.Sprintf("loki response: %s", e.status)
+}
+
+func retryableLoki(err error) bool {
+	var se *lokiStatusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	return true
+}
+
+func recordLokiDropped(n int) {
+	counter, err := otel.Meter(globalCfg.ServiceName).Int64Counter("loki_dropped_total")
+	if err != nil {
+		return
+	}
+	counter.Add(context.Background(), int64(n))
+}
+
+func sendLoki(ctx context.Context, entries []LokiEntry) error {
 	if !globalCfg.EnableLoki {
 		return nil
 	}
-	ts := time.Now().Add(-5 * time.Second).UnixNano()
-	body := map[string]interface{}{
-		"streams": []map[string]interface{}{
-			{
-				"stream": entry.Labels,
-				"values": [][2]string{{fmt.Sprintf("%d", ts), entry.Message}},
-			},
-		},
+	data, err := buildLokiPayload(entries)
+	if err != nil {
+		return err
+	}
+	gzipped := globalCfg.LokiCompression != "none"
+	if gzipped {
+		if data, err = gzipBytes(data); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, lokiTimeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, globalCfg.LokiURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	switch {
+	case globalCfg.LokiUsername != "":
+		req.SetBasicAuth(globalCfg.LokiUsername, globalCfg.LokiPassword)
+	case globalCfg.LokiToken != "":
+		req.Header.Set("Authorization", "Bearer "+globalCfg.LokiToken)
+	}
+	if globalCfg.LokiTenantID != "" {
+		req.Header.Set("X-Scope-OrgID", globalCfg.LokiTenantID)
 	}
-	data, _ := json.Marshal(body)
-	resp, err := http.Post(globalCfg.LokiURL, "application/json", bytes.NewBuffer(data))
+	resp, err := lokiClient().Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("loki response: %s", resp.Status)
+		return &lokiStatusError{status: resp.Status, code: resp.StatusCode}
 	}
 	return nil
 }
+
+func lokiClient() *http.Client {
+	if globalCfg.LokiClient != nil {
+		return globalCfg.LokiClient
+	}
+	return http.DefaultClient
+}
+
+func lokiTimeout() time.Duration {
+	if globalCfg.LokiTimeout > 0 {
+		return globalCfg.LokiTimeout
+	}
+	return 5 * time.Second
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// labelsKey returns a canonical string for a label set so entries with the
+// same labels end up in the same Loki stream.
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(labels[k])
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
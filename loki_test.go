@@ -0,0 +1,539 @@
+package eotel
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+type fakeLoki struct {
+	*httptest.Server
+	mu       sync.Mutex
+	messages []string
+	stamps   []string
+	labels   []map[string]string
+	metadata []map[string]string
+	pushes   []int
+	failNext int
+	encoding []string
+}
+
+func newFakeLoki(t *testing.T) *fakeLoki {
+	f := &fakeLoki{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		if f.failNext > 0 {
+			f.failNext--
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		f.mu.Unlock()
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			reader = zr
+		}
+		var body struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values []lokiValue       `json:"values"`
+			} `json:"streams"`
+		}
+		if err := json.NewDecoder(reader).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		n := 0
+		for _, s := range body.Streams {
+			for _, v := range s.Values {
+				f.messages = append(f.messages, v.Line)
+				f.stamps = append(f.stamps, v.Timestamp)
+				f.metadata = append(f.metadata, v.Metadata)
+				f.labels = append(f.labels, s.Stream)
+				n++
+			}
+		}
+		f.pushes = append(f.pushes, n)
+		f.encoding = append(f.encoding, r.Header.Get("Content-Encoding"))
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeLoki) Messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.messages...)
+}
+
+// Timestamps returns the timestamp pushed with each message, in order.
+func (f *fakeLoki) Timestamps() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.stamps...)
+}
+
+// Labels returns the stream labels each message was pushed under, in order.
+func (f *fakeLoki) Labels() []map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]string(nil), f.labels...)
+}
+
+// Metadata returns the structured metadata pushed with each message, in
+// order.
+func (f *fakeLoki) Metadata() []map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]string(nil), f.metadata...)
+}
+
+// Encodings returns the Content-Encoding header of each accepted request.
+func (f *fakeLoki) Encodings() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.encoding...)
+}
+
+// FailNext makes the next n requests fail with 503.
+func (f *fakeLoki) FailNext(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext = n
+}
+
+// Pushes returns the number of entries carried by each request received.
+func (f *fakeLoki) Pushes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.pushes...)
+}
+
+func TestLokiBatchesBySize(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{
+		ServiceName:       "test-service",
+		EnableLoki:        true,
+		LokiURL:           loki.URL,
+		LokiBatchSize:     100,
+		LokiFlushInterval: time.Hour,
+	})
+
+	for i := 0; i < 250; i++ {
+		defaultExporter{}.Send("info", fmt.Sprintf("entry %d", i), "trace", "span")
+	}
+	require.NoError(t, flushLoki(context.Background()))
+
+	assert.Equal(t, []int{100, 100, 50}, loki.Pushes())
+	assert.Len(t, loki.Messages(), 250)
+}
+
+func TestLokiFlushesOnInterval(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{
+		ServiceName:       "test-service",
+		EnableLoki:        true,
+		LokiURL:           loki.URL,
+		LokiBatchSize:     100,
+		LokiFlushInterval: 20 * time.Millisecond,
+	})
+
+	defaultExporter{}.Send("info", "lonely entry", "trace", "span")
+
+	assert.Eventually(t, func() bool {
+		return len(loki.Pushes()) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestLokiGroupsEntriesByLabels(t *testing.T) {
+	data, err := buildLokiPayload([]LokiEntry{
+		{Labels: map[string]string{"level": "info"}, Message: "a"},
+		{Labels: map[string]string{"level": "warn"}, Message: "b"},
+		{Labels: map[string]string{"level": "info"}, Message: "c"},
+	})
+	require.NoError(t, err)
+
+	var push lokiPush
+	require.NoError(t, json.Unmarshal(data, &push))
+	require.Len(t, push.Streams, 2)
+	assert.Equal(t, "info", push.Streams[0].Stream["level"])
+	require.Len(t, push.Streams[0].Values, 2)
+	assert.Equal(t, "a", push.Streams[0].Values[0].Line)
+	assert.Equal(t, "c", push.Streams[0].Values[1].Line)
+	assert.Equal(t, "warn", push.Streams[1].Stream["level"])
+}
+
+func TestLokiPayloadKeepsEntryTimestamps(t *testing.T) {
+	first := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Millisecond)
+	data, err := buildLokiPayload([]LokiEntry{
+		{Labels: map[string]string{"level": "info"}, Message: "later", Timestamp: second},
+		{Labels: map[string]string{"level": "info"}, Message: "earlier", Timestamp: first},
+	})
+	require.NoError(t, err)
+
+	var push lokiPush
+	require.NoError(t, json.Unmarshal(data, &push))
+	require.Len(t, push.Streams, 1)
+	assert.Equal(t, []lokiValue{
+		{Timestamp: strconv.FormatInt(first.UnixNano(), 10), Line: "earlier"},
+		{Timestamp: strconv.FormatInt(second.UnixNano(), 10), Line: "later"},
+	}, push.Streams[0].Values)
+}
+
+func TestLokiPushCarriesLogTime(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL, LokiFlushInterval: time.Hour})
+
+	before := time.Now()
+	New(context.Background(), "TestLogger").Info("timed")
+	after := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, Shutdown(context.Background()))
+
+	stamps := loki.Timestamps()
+	require.Len(t, stamps, 1)
+	ns, err := strconv.ParseInt(stamps[0], 10, 64)
+	require.NoError(t, err)
+	logged := time.Unix(0, ns)
+	assert.False(t, logged.Before(before), "pushed %v, logged after %v", logged, before)
+	assert.False(t, logged.After(after), "pushed %v, logged before %v", logged, after)
+}
+
+func TestLokiRetriesTransientFailures(t *testing.T) {
+	loki := newFakeLoki(t)
+	loki.FailNext(2)
+	initTestEOTEL(t, Config{
+		ServiceName:        "test-service",
+		EnableLoki:         true,
+		LokiURL:            loki.URL,
+		LokiMaxRetries:     3,
+		LokiRetryBaseDelay: time.Millisecond,
+	})
+
+	defaultExporter{}.Send("info", "eventually delivered", "trace", "span")
+	require.NoError(t, flushLoki(context.Background()))
+
+	assert.Equal(t, []string{"eventually delivered"}, loki.Messages())
+}
+
+func TestLokiCountsDroppedEntriesAfterRetries(t *testing.T) {
+	reader := newMetricReader(t)
+	loki := newFakeLoki(t)
+	loki.FailNext(10)
+	initTestEOTEL(t, Config{
+		ServiceName:        "test-service",
+		EnableLoki:         true,
+		LokiURL:            loki.URL,
+		LokiMaxRetries:     2,
+		LokiRetryBaseDelay: time.Millisecond,
+	})
+
+	defaultExporter{}.Send("info", "lost", "trace", "span")
+	defaultExporter{}.Send("info", "lost too", "trace", "span")
+	require.NoError(t, flushLoki(context.Background()))
+
+	assert.Empty(t, loki.Messages())
+	assert.Equal(t, int64(2), counterValue(t, reader, "loki_dropped_total"))
+}
+
+func TestLokiCompression(t *testing.T) {
+	for _, tc := range []struct {
+		compression string
+		encoding    string
+	}{
+		{compression: "gzip", encoding: "gzip"},
+		{compression: "none", encoding: ""},
+	} {
+		t.Run(tc.compression, func(t *testing.T) {
+			loki := newFakeLoki(t)
+			initTestEOTEL(t, Config{
+				ServiceName:     "test-service",
+				EnableLoki:      true,
+				LokiURL:         loki.URL,
+				LokiCompression: tc.compression,
+			})
+
+			defaultExporter{}.Send("info", "first line", "trace", "span")
+			defaultExporter{}.Send("warn", "second line", "trace", "span")
+			require.NoError(t, flushLoki(context.Background()))
+
+			assert.Equal(t, []string{tc.encoding}, loki.Encodings())
+			assert.Equal(t, []string{"first line", "second line"}, loki.Messages())
+		})
+	}
+}
+
+func TestLokiSenderStopsOnContextCancel(t *testing.T) {
+	loki := newFakeLoki(t)
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	initTestEOTELContext(t, ctx, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+	New(context.Background(), "TestLogger").Info("queued")
+
+	s := currentLoki()
+	cancel()
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("loki sender did not exit")
+	}
+	assert.Equal(t, []string{"queued"}, loki.Messages())
+
+	// Logging and flushing after the sender is gone must not block.
+	New(context.Background(), "TestLogger").Info("dropped")
+	require.NoError(t, flushLoki(context.Background()))
+	http.DefaultClient.CloseIdleConnections()
+}
+
+func TestLokiStaticLabels(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{
+		ServiceName: "test-service",
+		EnableLoki:  true,
+		LokiURL:     loki.URL,
+		LokiLabels:  map[string]string{"env": "prod", "region": "eu-west-1", "level": "bogus", "service": "bogus"},
+	})
+
+	New(context.Background(), "TestLogger").Warn("labelled")
+	require.NoError(t, Shutdown(context.Background()))
+
+	labels := loki.Labels()
+	require.Len(t, labels, 1)
+	assert.Equal(t, "prod", labels[0]["env"])
+	assert.Equal(t, "eu-west-1", labels[0]["region"])
+	assert.Equal(t, "warn", labels[0]["level"])
+	assert.Equal(t, "test-service", labels[0]["service"])
+}
+
+func TestWithLabelAddsLokiStreamLabel(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL})
+
+	logger := New(context.Background(), "TestLogger")
+	logger.WithLabel("tenant", "acme").WithLabel("level", "bogus").Info("tenant line")
+	logger.Info("plain line")
+	require.NoError(t, Shutdown(context.Background()))
+
+	labels := loki.Labels()
+	require.Len(t, labels, 2)
+	assert.Equal(t, "acme", labels[0]["tenant"])
+	assert.Equal(t, "info", labels[0]["level"])
+	assert.NotContains(t, labels[1], "tenant")
+}
+
+func TestWithLabelIsCapped(t *testing.T) {
+	logger := New(context.Background(), "TestLogger")
+	for i := range maxLokiLabels + 2 {
+		logger = logger.WithLabel(fmt.Sprintf("label_%d", i), "v")
+	}
+	assert.Len(t, logger.(*Eotel).labels, maxLokiLabels)
+	assert.Equal(t, "w", logger.WithLabel("label_0", "w").(*Eotel).labels["label_0"])
+}
+
+func TestLokiStructuredMetadata(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL, LokiStructuredMetadata: true})
+
+	logger := New(context.Background(), "TestLogger").WithField("order_id", 42)
+	logger.InfoAttrs("order shipped", map[string]any{"carrier": "dhl"})
+	require.NoError(t, Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"order shipped"}, loki.Messages())
+	metadata := loki.Metadata()
+	require.Len(t, metadata, 1)
+	assert.Equal(t, map[string]string{"order_id": "42", "carrier": "dhl"}, metadata[0])
+	assert.NotContains(t, loki.Labels()[0], "order_id")
+}
+
+func TestLokiMinLevelSkipsLowerLevels(t *testing.T) {
+	loki := newFakeLoki(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL, LokiMinLevel: "warn"})
+
+	logger, logs := newObservedLogger("TestLogger")
+	logger.Info("kept local")
+	logger.Warn("pushed")
+	logger.Error("pushed too")
+	require.NoError(t, Shutdown(context.Background()))
+
+	assert.Equal(t, []string{"pushed", "pushed too"}, loki.Messages())
+	assert.Equal(t, 3, logs.Len())
+}
+
+func TestLokiValueWithoutMetadataIsPair(t *testing.T) {
+	data, err := json.Marshal(lokiValue{Timestamp: "1", Line: "plain"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["1", "plain"]`, string(data))
+
+	data, err = json.Marshal(lokiValue{Timestamp: "1", Line: "rich", Metadata: map[string]string{"k": "v"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["1", "rich", {"k": "v"}]`, string(data))
+}
+
+// stallLoki starts Loki with cfg against an endpoint that holds every push
+// until the test ends, so the sender stops draining its queue.
+func stallLoki(t *testing.T, cfg Config) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	cfg.ServiceName = "test-service"
+	cfg.EnableLoki = true
+	cfg.LokiURL = srv.URL
+	cfg.LokiBatchSize = 1
+	cfg.LokiMaxRetries = -1
+	initTestEOTEL(t, cfg)
+	// Registered after initTestEOTEL so it runs before its Shutdown.
+	t.Cleanup(func() { close(release) })
+}
+
+func TestLokiOverflowDropDoesNotBlock(t *testing.T) {
+	reader := newMetricReader(t)
+	stallLoki(t, Config{LokiOverflowPolicy: "drop"})
+
+	logger := New(context.Background(), "TestLogger")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 5 * lokiQueueSize() {
+			logger.Infof("request %d", i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on a stalled Loki")
+	}
+	assert.Positive(t, counterValue(t, reader, "loki_dropped_total"))
+}
+
+func TestLokiOverflowTimeoutWaitsThenDrops(t *testing.T) {
+	reader := newMetricReader(t)
+	stallLoki(t, Config{LokiOverflowPolicy: "timeout", LokiOverflowTimeout: 10 * time.Millisecond})
+
+	logger := New(context.Background(), "TestLogger")
+	start := time.Now()
+	for i := range lokiQueueSize() + 3 {
+		logger.Infof("request %d", i)
+	}
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Positive(t, counterValue(t, reader, "loki_dropped_total"))
+}
+
+func TestLokiPushTimesOut(t *testing.T) {
+	reader := newMetricReader(t)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	initTestEOTEL(t, Config{
+		ServiceName:    "test-service",
+		EnableLoki:     true,
+		LokiURL:        srv.URL,
+		LokiTimeout:    20 * time.Millisecond,
+		LokiMaxRetries: -1,
+	})
+
+	start := time.Now()
+	err := sendLoki(context.Background(), []LokiEntry{{Message: "slow"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	pushLoki(context.Background(), []LokiEntry{{Message: "slow"}})
+	assert.Equal(t, int64(1), counterValue(t, reader, "loki_dropped_total"))
+}
+
+func TestPushLokiStopsRetryingWhenCtxDone(t *testing.T) {
+	reader := newMetricReader(t)
+	loki := newFakeLoki(t)
+	loki.FailNext(100)
+	initTestEOTEL(t, Config{
+		ServiceName:        "test-service",
+		EnableLoki:         true,
+		LokiURL:            loki.URL,
+		LokiMaxRetries:     10,
+		LokiRetryBaseDelay: time.Second,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	pushLoki(ctx, []LokiEntry{{Message: "abandoned"}})
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int64(1), counterValue(t, reader, "loki_dropped_total"))
+}
+
+func TestLokiUsesConfiguredClient(t *testing.T) {
+	loki := newFakeLoki(t)
+	var used bool
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableLoki: true, LokiURL: loki.URL, LokiClient: client})
+
+	require.NoError(t, sendLoki(context.Background(), []LokiEntry{{Message: "via client"}}))
+	assert.True(t, used)
+	assert.Equal(t, []string{"via client"}, loki.Messages())
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestLokiAuthHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantAuth string
+	}{
+		{"basic", Config{LokiUsername: "123456", LokiPassword: "glc_key", LokiTenantID: "tenant-a"}, "Basic MTIzNDU2OmdsY19rZXk="},
+		{"bearer", Config{LokiToken: "s3cret", LokiTenantID: "tenant-a"}, "Bearer s3cret"},
+		{"none", Config{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var auth, tenant string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth, tenant = r.Header.Get("Authorization"), r.Header.Get("X-Scope-OrgID")
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+			cfg := tt.cfg
+			cfg.ServiceName, cfg.EnableLoki, cfg.LokiURL = "test-service", true, srv.URL
+			initTestEOTEL(t, cfg)
+
+			require.NoError(t, sendLoki(context.Background(), []LokiEntry{{Message: "authed"}}))
+			assert.Equal(t, tt.wantAuth, auth)
+			assert.Equal(t, tt.cfg.LokiTenantID, tenant)
+		})
+	}
+}
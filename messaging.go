@@ -0,0 +1,52 @@
+package eotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartProducerSpan starts a producer span for publishing to topic and
+// returns its context with a logger bound to it. Pass the context to
+// InjectTraceContext to stamp the message headers, so StartConsumerSpan can
+// link back to it, and End the logger once the message is sent.
+func StartProducerSpan(ctx context.Context, topic string) (context.Context, Logger) {
+	l := New(ctx, "publish "+topic).(*Eotel)
+	c := l.child("publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			attribute.String("messaging.operation", "publish"),
+		),
+	)
+	return c.ctx, c
+}
+
+// StartConsumerSpan starts a consumer span for processing a message from
+// topic under ctx. The span links to the producer span found in the message
+// headers, and baggage sent with the message is added to the returned
+// context.
+func StartConsumerSpan(ctx context.Context, topic string, headers map[string]string) (context.Context, Logger) {
+	sent := ExtractTraceContext(context.Background(), MapCarrier(headers))
+	if b := baggage.FromContext(sent); b.Len() > 0 {
+		ctx = baggage.ContextWithBaggage(ctx, b)
+	}
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			attribute.String("messaging.operation", "process"),
+		),
+	}
+	if sc := trace.SpanContextFromContext(sent); sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+	}
+	l := New(ctx, "process "+topic).(*Eotel)
+	c := l.child("process "+topic, opts...)
+	return c.ctx, c
+}
@@ -0,0 +1,59 @@
+package eotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMessagingSpansLinkProducerToConsumer(t *testing.T) {
+	sr := newSpanRecorder(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+
+	member, err := baggage.NewMember("tenant", "acme")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+	pubCtx, producer := StartProducerSpan(baggage.ContextWithBaggage(context.Background(), bag), "orders")
+	headers := map[string]string{}
+	InjectTraceContext(pubCtx, MapCarrier(headers))
+	producer.End()
+
+	ctx, consumer := StartConsumerSpan(context.Background(), "orders", headers)
+	assert.Equal(t, "acme", baggage.FromContext(ctx).Member("tenant").Value())
+	consumer.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	pub, proc := spans[0], spans[1]
+	assert.Equal(t, "publish orders", pub.Name())
+	assert.Equal(t, trace.SpanKindProducer, pub.SpanKind())
+	assert.Contains(t, pub.Attributes(), attribute.String("messaging.system", "kafka"))
+	assert.Contains(t, pub.Attributes(), attribute.String("messaging.destination.name", "orders"))
+	assert.Contains(t, pub.Attributes(), attribute.String("messaging.operation", "publish"))
+
+	assert.Equal(t, "process orders", proc.Name())
+	assert.Equal(t, trace.SpanKindConsumer, proc.SpanKind())
+	assert.Contains(t, proc.Attributes(), attribute.String("messaging.system", "kafka"))
+	assert.Contains(t, proc.Attributes(), attribute.String("messaging.destination.name", "orders"))
+	assert.Contains(t, proc.Attributes(), attribute.String("messaging.operation", "process"))
+	require.Len(t, proc.Links(), 1)
+	assert.Equal(t, pub.SpanContext().TraceID(), proc.Links()[0].SpanContext.TraceID())
+	assert.Equal(t, pub.SpanContext().SpanID(), proc.Links()[0].SpanContext.SpanID())
+	assert.NotEqual(t, pub.SpanContext().TraceID(), proc.SpanContext().TraceID())
+}
+
+func TestConsumerSpanWithoutHeadersHasNoLink(t *testing.T) {
+	sr := newSpanRecorder(t)
+	_, consumer := StartConsumerSpan(context.Background(), "orders", nil)
+	consumer.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Empty(t, spans[0].Links())
+}
@@ -0,0 +1,88 @@
+package eotel
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type instrumentKey struct {
+	meter metric.Meter
+	name  string
+}
+
+// instruments caches the application instruments created by Counter, Gauge
+// and Histogram, per meter and name.
+var instruments sync.Map
+
+var errNilInstrument = errors.New("meter returned a nil instrument")
+
+// cachedInstrument returns the instrument cached for name on m, creating it
+// on first use. A name already used for another instrument kind is created
+// afresh (and left uncached) so the caller still gets the kind it asked for.
+func cachedInstrument[T any](m metric.Meter, name string, create func() (T, error)) (T, error) {
+	if !reflect.TypeOf(m).Comparable() {
+		return newInstrument(create)
+	}
+	key := instrumentKey{meter: m, name: name}
+	if v, ok := instruments.Load(key); ok {
+		if inst, ok := v.(T); ok {
+			return inst, nil
+		}
+		return newInstrument(create)
+	}
+	inst, err := newInstrument(create)
+	if err != nil {
+		return inst, err
+	}
+	v, _ := instruments.LoadOrStore(key, inst)
+	if cached, ok := v.(T); ok {
+		return cached, nil
+	}
+	return inst, nil
+}
+
+// newInstrument calls create, treating a nil instrument as an error.
+func newInstrument[T any](create func() (T, error)) (T, error) {
+	inst, err := create()
+	if err == nil && any(inst) == nil {
+		err = errNilInstrument
+	}
+	return inst, err
+}
+
+// Counter adds value to the application counter name.
+func (l *Eotel) Counter(name string, value int64, attrs ...attribute.KeyValue) {
+	c, err := cachedInstrument(l.meter, name, func() (metric.Int64Counter, error) {
+		return l.meter.Int64Counter(name)
+	})
+	if err != nil {
+		return
+	}
+	c.Add(l.ctx, value, metric.WithAttributes(attrs...))
+}
+
+// Gauge sets the application gauge name to value.
+func (l *Eotel) Gauge(name string, value float64, attrs ...attribute.KeyValue) {
+	g, err := cachedInstrument(l.meter, name, func() (metric.Float64Gauge, error) {
+		return l.meter.Float64Gauge(name)
+	})
+	if err != nil {
+		return
+	}
+	g.Record(l.ctx, value, metric.WithAttributes(attrs...))
+}
+
+// Histogram records value in the application histogram name.
+func (l *Eotel) Histogram(name string, value float64, attrs ...attribute.KeyValue) {
+	h, err := cachedInstrument(l.meter, name, func() (metric.Float64Histogram, error) {
+		return l.meter.Float64Histogram(name)
+	})
+	if err != nil {
+		return
+	}
+	h.Record(l.ctx, value, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,171 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestCachedInstrumentReusesInstrument(t *testing.T) {
+	newMetricReader(t)
+	meter := otel.Meter("test")
+	created := 0
+	create := func() (metric.Int64Counter, error) {
+		created++
+		return meter.Int64Counter("cached_total")
+	}
+
+	first, err := cachedInstrument(meter, "cached_total", create)
+	require.NoError(t, err)
+	second, err := cachedInstrument(meter, "cached_total", create)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, first, second)
+}
+
+func TestApplicationMetrics(t *testing.T) {
+	reader := newMetricReader(t)
+	logger := New(context.Background(), "TestLogger")
+
+	logger.Counter("orders_total", 2, attribute.String("status", "paid"))
+	logger.Counter("orders_total", 3, attribute.String("status", "paid"))
+	logger.Histogram("order_amount", 12.5)
+	logger.Histogram("order_amount", 7.5)
+	logger.Gauge("queue_depth", 4)
+	logger.Gauge("queue_depth", 9)
+
+	assert.Equal(t, int64(5), counterValue(t, reader, "orders_total"))
+	assert.Equal(t, uint64(2), histogramCount(t, reader, "order_amount"))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var gauge []float64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if g, ok := m.Data.(metricdata.Gauge[float64]); ok && m.Name == "queue_depth" {
+				for _, dp := range g.DataPoints {
+					gauge = append(gauge, dp.Value)
+				}
+			}
+		}
+	}
+	assert.Equal(t, []float64{9}, gauge)
+}
+
+// failingMeterProvider hands out meters whose instrument constructors fail
+// and return nil instruments.
+type failingMeterProvider struct{ metricnoop.MeterProvider }
+
+func (failingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter { return failingMeter{} }
+
+type failingMeter struct{ metricnoop.Meter }
+
+func (failingMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errors.New("counter unavailable")
+}
+
+func (failingMeter) Float64Histogram(string, ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return nil, errors.New("histogram unavailable")
+}
+
+func TestNewSurvivesFailingMeter(t *testing.T) {
+	otel.SetMeterProvider(failingMeterProvider{})
+	t.Cleanup(func() { otel.SetMeterProvider(metricnoop.NewMeterProvider()) })
+	var (
+		mu       sync.Mutex
+		reported []error
+	)
+	prevHandler := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}))
+	t.Cleanup(func() { otel.SetErrorHandler(prevHandler) })
+
+	logger := New(context.Background(), "TestLogger")
+	assert.NotPanics(t, func() {
+		logger.Info("still logging")
+		logger.Child("child").Error("still logging")
+		logger.End()
+		logger.Counter("orders_total", 1)
+		logger.Histogram("order_amount", 1)
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, reported)
+}
+
+func TestTimerStopRecordsEventAndHistogram(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+	logger := New(context.Background(), "TestLogger").Child("job")
+
+	timer := logger.Start("load")
+	time.Sleep(time.Millisecond)
+	elapsed := timer.Stop()
+	logger.End()
+
+	assert.GreaterOrEqual(t, elapsed, time.Millisecond)
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "load", spans[0].Events()[0].Name)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var operations []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == "operation_duration_ms" {
+				for _, dp := range h.DataPoints {
+					op, _ := dp.Attributes.Value("operation")
+					operations = append(operations, op.AsString())
+					assert.GreaterOrEqual(t, dp.Sum, 1.0)
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{"load"}, operations)
+}
+
+func TestTimerStopWithError(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+	logger := New(context.Background(), "TestLogger").Child("job")
+
+	logger.Start("ok").StopWithError(nil)
+	failing := func() (err error) {
+		timer := logger.Start("fetch")
+		defer func() { timer.StopWithError(err) }()
+		return fmt.Errorf("fetch: %w", &notFoundError{id: "9"})
+	}
+	require.Error(t, failing())
+	logger.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "ok", events[0].Name)
+	assert.Equal(t, []attribute.KeyValue{events[0].Attributes[0]}, events[0].Attributes)
+	assert.Equal(t, "fetch", events[1].Name)
+	assert.Contains(t, events[1].Attributes, attribute.String("error", "fetch: not found: 9"))
+	assert.Contains(t, events[1].Attributes, attribute.String("error.type", "*eotel.notFoundError"))
+
+	assert.Equal(t, int64(1), counterValue(t, reader, "operation_errors_total"))
+	assert.Equal(t, uint64(2), histogramCount(t, reader, "operation_duration_ms"))
+}
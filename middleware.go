@@ -1,16 +1,106 @@
 package eotel
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func Middleware(name string) gin.HandlerFunc {
+// MiddlewareOption configures Middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	bodyLimit    int
+	skip         []func(*http.Request) bool
+	repanic      bool
+	panicHandler func(c *gin.Context, rec any)
+}
+
+// WithSkipPaths makes Middleware pass requests for the given paths straight
+// to the handler, with no span, logger or metrics, e.g. health checks. A path
+// ending in "*" matches by prefix ("/debug/*"); any other must match exactly.
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return WithSkip(func(r *http.Request) bool {
+		for _, p := range paths {
+			if prefix, ok := strings.CutSuffix(p, "*"); ok {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					return true
+				}
+			} else if r.URL.Path == p {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithSkip is like WithSkipPaths but skips every request for which skip
+// returns true.
+func WithSkip(skip func(*http.Request) bool) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.skip = append(o.skip, skip)
+	}
+}
+
+// WithRepanic makes Middleware panic again with the recovered value once it
+// has logged the panic, reported it to Sentry and failed the request span,
+// instead of answering 500 itself, so a recovery layer further up (or the
+// test runner) still sees it.
+func WithRepanic() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.repanic = true
+	}
+}
+
+// WithPanicHandler is like WithRepanic but hands the recovered value to fn,
+// which is responsible for writing the response.
+func WithPanicHandler(fn func(c *gin.Context, rec any)) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.panicHandler = fn
+	}
+}
+
+func (o *middlewareOptions) skipped(r *http.Request) bool {
+	for _, skip := range o.skip {
+		if skip(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func Middleware(name string, opts ...MiddlewareOption) gin.HandlerFunc {
+	var o middlewareOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return func(c *gin.Context) {
-		// Start root span
-		ctx, span := otel.Tracer(globalCfg.ServiceName).
-			Start(c.Request.Context(), fmt.Sprintf("%s %s", c.Request.Method, c.FullPath()))
+		if o.skipped(c.Request) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+
+		// Extract incoming trace context and baggage
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// Start root span, named after the route template rather than the
+		// concrete path to keep span names few
+		ctx, span := Tracer().Start(ctx, strings.TrimSpace(c.Request.Method+" "+c.FullPath()),
+			trace.WithAttributes(
+				attribute.String("http.route", c.FullPath()),
+				attribute.String("url.path", c.Request.URL.Path),
+			))
 		defer span.End()
 
 		// Create logger
@@ -18,15 +108,85 @@ func Middleware(name string) gin.HandlerFunc {
 			WithField("method", c.Request.Method).
 			WithField("path", c.Request.URL.Path).
 			WithField("ip", c.ClientIP()).
-			WithField("ua", c.Request.UserAgent())
+			WithField("ua", c.Request.UserAgent()).
+			WithRequestID(ResolveRequestID(span, c.Request.Header.Get, c.Writer.Header().Set))
+
+		defer logger.End()
 
 		// Inject logger into context
-		ctx = logger.Inject(ctx, logger)
+		ctx = Inject(ctx, logger)
 		c.Request = c.Request.WithContext(ctx)
 
+		// Keep the bodies for error responses
+		var reqBody, respBody *cappedBuffer
+		if o.bodyLimit > 0 {
+			reqBody = captureRequestBody(c.Request, o.bodyLimit)
+			respBody = &cappedBuffer{max: o.bodyLimit}
+			c.Writer = &bodyWriter{ResponseWriter: c.Writer, body: respBody}
+			defer func() {
+				if c.Writer.Status() < http.StatusBadRequest {
+					return
+				}
+				recordBody(span, "http.request.body", reqBody)
+				if textualContent(c.Writer.Header().Get("Content-Type")) {
+					recordBody(span, "http.response.body", respBody)
+				}
+			}()
+		}
+
 		// Recover panic + log + Sentry
-		defer logger.RecoverPanic(c)
+		defer func() {
+			if rec := recover(); rec != nil {
+				recoverHTTPPanic(FromGin(c, "panic"), span, NewPanicError(rec))
+				switch {
+				case o.repanic:
+					recordHTTPStatus(ctx, span, http.StatusInternalServerError, c.Writer.Size(), time.Since(start))
+					panic(rec)
+				case o.panicHandler != nil:
+					o.panicHandler(c, rec)
+					recordHTTPStatus(ctx, span, c.Writer.Status(), c.Writer.Size(), time.Since(start))
+				default:
+					c.AbortWithStatus(http.StatusInternalServerError)
+					recordHTTPStatus(ctx, span, http.StatusInternalServerError, c.Writer.Size(), time.Since(start))
+				}
+			}
+		}()
 
 		c.Next()
+
+		recordHTTPStatus(ctx, span, c.Writer.Status(), c.Writer.Size(), time.Since(start))
+	}
+}
+
+// recoverHTTPPanic logs a recovered panic through logger, which ends the
+// logger's span if it owns one, e.g. a child the handler injected. The
+// request span records err too when the line went to another span, so it is
+// marked failed either way.
+func recoverHTTPPanic(logger Logger, span trace.Span, err error) {
+	logger.WithError(err).Error("unhandled panic")
+	if !trace.SpanContextFromContext(logger.Ctx()).Equal(span.SpanContext()) {
+		failSpan(span, err)
+	}
+}
+
+// recordHTTPStatus sets the response status, size and latency on the request
+// span and records the latency in log_duration_ms by status class.
+func recordHTTPStatus(ctx context.Context, span trace.Span, status, size int, elapsed time.Duration) {
+	durationMs := float64(elapsed) / float64(time.Millisecond)
+	span.SetAttributes(
+		attribute.Int("http.status_code", status),
+		attribute.Int("http.response_size", max(size, 0)),
+		attribute.Float64("http.server.duration_ms", durationMs),
+	)
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	hist, err := otel.Meter(globalCfg.ServiceName).Float64Histogram("log_duration_ms")
+	if err != nil {
+		return
 	}
+	hist.Record(ctx, durationMs, metric.WithAttributes(
+		attribute.String("http.status_class", fmt.Sprintf("%dxx", status/100)),
+	))
 }
@@ -0,0 +1,382 @@
+package eotel
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func setPropagator(t *testing.T, p propagation.TextMapPropagator) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(p)
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+}
+
+func TestMiddlewareExtractsBaggage(t *testing.T) {
+	sr := newSpanRecorder(t)
+	setPropagator(t, propagation.Baggage{})
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/orders", func(c *gin.Context) {
+		New(c.Request.Context(), "handler").Child("lookup").Info("looking up orders")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("baggage", "tenant_id=acme")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var lookup bool
+	for _, s := range sr.Ended() {
+		if s.Name() == "lookup" {
+			lookup = true
+			assert.Contains(t, s.Attributes(), attribute.String("tenant_id", "acme"))
+		}
+	}
+	require.True(t, lookup)
+}
+
+func TestMiddlewareLogsOnRequestSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/orders", func(c *gin.Context) {
+		logger := FromGin(c, "handler").WithField("order_id", 42)
+		logger.SpanEvent("cache.miss")
+		logger.Info("listing orders")
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /orders", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("order_id", 42))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("log.message", "listing orders"))
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "cache.miss", spans[0].Events()[0].Name)
+}
+
+func TestMiddlewareRecordsStatus(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/missing", func(c *gin.Context) { c.String(http.StatusNotFound, "not found") })
+	r.GET("/broken", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+
+	missing := spans[0]
+	assert.Contains(t, missing.Attributes(), attribute.Int("http.status_code", http.StatusNotFound))
+	assert.Contains(t, missing.Attributes(), attribute.Int("http.response_size", len("not found")))
+	assert.Equal(t, codes.Unset, missing.Status().Code)
+
+	broken := spans[1]
+	assert.Contains(t, broken.Attributes(), attribute.Int("http.status_code", http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, broken.Status().Code)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	classes := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			h, ok := m.Data.(metricdata.Histogram[float64])
+			if m.Name != "log_duration_ms" || !ok {
+				continue
+			}
+			for _, dp := range h.DataPoints {
+				if class, ok := dp.Attributes.Value("http.status_class"); ok {
+					classes[class.AsString()] += dp.Count
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]uint64{"4xx": 1, "5xx": 1}, classes)
+}
+
+func TestMiddlewarePanicFailsRequestAndLoggerSpans(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/explode", func(c *gin.Context) {
+		InjectToGin(c, FromGin(c, "handler").Child("work"))
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/explode", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	work, root := spans[0], spans[1]
+	assert.Equal(t, "work", work.Name())
+	assert.Equal(t, "GET /explode", root.Name())
+	for _, s := range spans {
+		assert.Equal(t, codes.Error, s.Status().Code, s.Name())
+		require.NotEmpty(t, s.Events(), s.Name())
+		assert.Equal(t, "exception", s.Events()[0].Name)
+	}
+	assert.Contains(t, root.Attributes(), attribute.Int("http.status_code", http.StatusInternalServerError))
+}
+
+func TestMiddlewareCapturesBodiesOfErrorResponses(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	r := gin.New()
+	r.Use(Middleware("test", WithBodyCapture(16)))
+	var handlerSaw []string
+	r.POST("/orders", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		handlerSaw = append(handlerSaw, string(body))
+		if c.Query("ok") != "" {
+			c.Status(http.StatusCreated)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad"})
+	})
+	post := func(target, contentType, body string) {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	post("/orders", "application/json", `{"sku":"A1"}`)
+	post("/orders", "application/json", `{"sku":"A1","note":"this is far too long"}`)
+	post("/orders", "multipart/form-data; boundary=x", "--x--")
+	post("/orders?ok=1", "application/json", `{"sku":"A1"}`)
+	post("/orders", "", `{"sku":"A1"}`)
+	require.Len(t, handlerSaw, 5)
+	assert.Equal(t, `{"sku":"A1","note":"this is far too long"}`, handlerSaw[1])
+
+	spans := sr.Ended()
+	require.Len(t, spans, 5)
+	short := spans[0].Attributes()
+	assert.Contains(t, short, attribute.String("http.request.body", `{"sku":"A1"}`))
+	assert.Contains(t, short, attribute.String("http.response.body", `{"error":"bad"}`))
+	assert.NotContains(t, short, attribute.Bool("http.request.body.truncated", true))
+
+	long := spans[1].Attributes()
+	assert.Contains(t, long, attribute.String("http.request.body", `{"sku":"A1","not`))
+	assert.Contains(t, long, attribute.Bool("http.request.body.truncated", true))
+
+	for _, s := range spans[2:] {
+		for _, kv := range s.Attributes() {
+			assert.NotEqual(t, attribute.Key("http.request.body"), kv.Key)
+		}
+	}
+}
+
+func TestMiddlewareRedactsCapturedBodies(t *testing.T) {
+	sr := newSpanRecorder(t)
+	setRedaction(t, []string{"http.response.body"}, func(key string, value any) (any, bool) {
+		if s, ok := value.(string); ok && strings.Contains(s, "hunter2") {
+			return strings.ReplaceAll(s, "hunter2", "***"), true
+		}
+		return nil, false
+	})
+
+	r := gin.New()
+	r.Use(Middleware("test", WithBodyCapture(64)))
+	r.POST("/login", func(c *gin.Context) {
+		c.JSON(http.StatusUnauthorized, gin.H{"token": "t0k3n"})
+	})
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	attrs := spans[0].Attributes()
+	assert.Contains(t, attrs, attribute.String("http.request.body", `{"password":"***"}`))
+	assert.Contains(t, attrs, attribute.String("http.response.body", "***"))
+}
+
+func TestMiddlewareSkipsPaths(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	r := gin.New()
+	r.Use(Middleware("test",
+		WithSkipPaths("/healthz", "/debug/*"),
+		WithSkip(func(r *http.Request) bool { return r.Header.Get("X-Probe") != "" }),
+	))
+	var injected []bool
+	handler := func(c *gin.Context) {
+		_, ok := FromContextOK(c.Request.Context(), "handler")
+		injected = append(injected, ok)
+		c.Status(http.StatusOK)
+	}
+	r.GET("/healthz", handler)
+	r.GET("/healthz/deep", handler)
+	r.GET("/debug/vars", handler)
+	r.GET("/orders", handler)
+
+	for _, path := range []string{"/healthz", "/debug/vars", "/healthz/deep", "/orders"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	probe := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	probe.Header.Set("X-Probe", "1")
+	r.ServeHTTP(httptest.NewRecorder(), probe)
+
+	assert.Equal(t, []bool{false, false, true, true, false}, injected)
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "GET /healthz/deep", spans[0].Name())
+	assert.Equal(t, "GET /orders", spans[1].Name())
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	var ids []string
+	r.GET("/orders", func(c *gin.Context) {
+		ids = append(ids, FromGin(c, "handler").RequestID())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	sent := httptest.NewRecorder()
+	r.ServeHTTP(sent, req)
+	generated := httptest.NewRecorder()
+	r.ServeHTTP(generated, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	require.Len(t, ids, 2)
+	assert.Equal(t, "req-123", ids[0])
+	assert.Equal(t, "req-123", sent.Header().Get("X-Request-Id"))
+	assert.Len(t, ids[1], 32)
+	assert.Equal(t, ids[1], generated.Header().Get("X-Request-Id"))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("request_id", "req-123"))
+	assert.Contains(t, spans[1].Attributes(), attribute.String("request_id", ids[1]))
+}
+
+func TestMiddlewareRepanicsAfterLogging(t *testing.T) {
+	sr := newSpanRecorder(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+	transport := newSentryTransport(t)
+
+	r := gin.New()
+	var upstream any
+	r.Use(func(c *gin.Context) {
+		defer func() {
+			upstream = recover()
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+		}()
+		c.Next()
+	})
+	r.Use(Middleware("test", WithRepanic()))
+	r.GET("/explode", func(c *gin.Context) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/explode", nil))
+
+	assert.Equal(t, "boom", upstream)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Len(t, transport.Events(), 1)
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestMiddlewarePanicHandler(t *testing.T) {
+	r := gin.New()
+	var handled any
+	r.Use(Middleware("test", WithPanicHandler(func(c *gin.Context, rec any) {
+		handled = rec
+		c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "upstream failed"})
+	})))
+	r.GET("/explode", func(c *gin.Context) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/explode", nil))
+
+	assert.Equal(t, "boom", handled)
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+}
+
+func explodingHandler(c *gin.Context) {
+	panic("boom")
+}
+
+func TestMiddlewarePanicLogsStack(t *testing.T) {
+	sr := newSpanRecorder(t)
+	setCaptureStack(t, true)
+	core, logs := observer.New(zapcore.DebugLevel)
+	prev := baseLogger
+	baseLogger = zap.New(core)
+	t.Cleanup(func() { baseLogger = prev })
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/explode", explodingHandler)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/explode", nil))
+
+	entries := logs.FilterMessage("unhandled panic").All()
+	require.Len(t, entries, 1)
+	stack, ok := entries[0].ContextMap()["stacktrace"].(string)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(stack, "github.com/nicedev97/eotel.explodingHandler\n"), stack)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("stacktrace", stack))
+}
+
+func TestFromGinReturnsIsolatedLoggers(t *testing.T) {
+	newSpanRecorder(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	prev := baseLogger
+	baseLogger = zap.New(core)
+	t.Cleanup(func() { baseLogger = prev })
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/orders", func(c *gin.Context) {
+		FromGin(c, "handler").WithField("step", "validate").Info("validated")
+		FromGin(c, "handler").Info("listed")
+		assert.NotSame(t, FromGin(c, "handler"), FromGin(c, "handler"))
+		c.Status(http.StatusOK)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	entries := logs.All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "validate", entries[0].ContextMap()["step"])
+	assert.NotContains(t, entries[1].ContextMap(), "step")
+	assert.Equal(t, "/orders", entries[1].ContextMap()["path"])
+}
@@ -0,0 +1,96 @@
+package eotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewNop returns a Logger that emits nothing: no zap output, spans, metrics,
+// Loki pushes or Sentry events. It is meant for tests and for running with
+// telemetry disabled altogether.
+func NewNop() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+type nopTimer struct{}
+
+func (nopTimer) Stop() time.Duration               { return 0 }
+func (nopTimer) StopWithError(error) time.Duration { return 0 }
+
+func (nopLogger) Info(string)  {}
+func (nopLogger) Error(string) {}
+func (nopLogger) Debug(string) {}
+func (nopLogger) Warn(string)  {}
+func (nopLogger) Fatal(string) {}
+
+func (nopLogger) InfoCtx(context.Context, string)  {}
+func (nopLogger) ErrorCtx(context.Context, string) {}
+func (nopLogger) DebugCtx(context.Context, string) {}
+func (nopLogger) WarnCtx(context.Context, string)  {}
+func (nopLogger) FatalCtx(context.Context, string) {}
+
+func (nopLogger) Infof(string, ...any)  {}
+func (nopLogger) Errorf(string, ...any) {}
+func (nopLogger) Debugf(string, ...any) {}
+func (nopLogger) Warnf(string, ...any)  {}
+func (nopLogger) Fatalf(string, ...any) {}
+func (nopLogger) InfoIf(bool, string)   {}
+func (nopLogger) Enabled(string) bool   { return false }
+
+func (nopLogger) InfoAttrs(string, map[string]any)  {}
+func (nopLogger) ErrorAttrs(string, map[string]any) {}
+func (nopLogger) DebugAttrs(string, map[string]any) {}
+func (nopLogger) WarnAttrs(string, map[string]any)  {}
+func (nopLogger) FatalAttrs(string, map[string]any) {}
+
+func (n nopLogger) WithField(string, any) Logger                   { return n }
+func (n nopLogger) WithFields(map[string]any) Logger               { return n }
+func (n nopLogger) WithStr(string, string) Logger                  { return n }
+func (n nopLogger) WithInt(string, int) Logger                     { return n }
+func (n nopLogger) WithFloat(string, float64) Logger               { return n }
+func (n nopLogger) WithBool(string, bool) Logger                   { return n }
+func (n nopLogger) WithDuration(string, time.Duration) Logger      { return n }
+func (n nopLogger) WithError(error) Logger                         { return n }
+func (n nopLogger) WithStack() Logger                              { return n }
+func (n nopLogger) WithoutStack() Logger                           { return n }
+func (n nopLogger) WithBaggage(string, string) Logger              { return n }
+func (n nopLogger) WithLabel(string, string) Logger                { return n }
+func (n nopLogger) WithRequestID(string) Logger                    { return n }
+func (n nopLogger) WithContext(context.Context) Logger             { return n }
+func (nopLogger) End()                                             {}
+func (nopLogger) Sync() error                                      { return nil }
+func (n nopLogger) Clone() Logger                                  { return n }
+func (n nopLogger) StartLinked(string, ...trace.Link) Logger       { return n }
+func (n nopLogger) StartRoot(string) Logger                        { return n }
+func (n nopLogger) ChildKind(string, trace.SpanKind) Logger        { return n }
+func (n nopLogger) Child(string) Logger                            { return n }
+func (nopLogger) WithTracer(_ string, fn func(context.Context))    { fn(context.Background()) }
+func (n nopLogger) WithSpan(_ string, fn func(Logger) error) error { return fn(n) }
+func (nopLogger) SpanEvent(string, ...attribute.KeyValue)          {}
+func (nopLogger) SetSpanAttr(string, any)                          {}
+func (nopLogger) SetSpanError(error, ...attribute.KeyValue)        {}
+func (nopLogger) CaptureMessage(string, string)                    {}
+func (nopLogger) Ctx() context.Context                             { return context.Background() }
+func (nopLogger) Start(string) Timer                               { return nopTimer{} }
+func (nopLogger) Counter(string, int64, ...attribute.KeyValue)     {}
+func (nopLogger) Gauge(string, float64, ...attribute.KeyValue)     {}
+func (nopLogger) Histogram(string, float64, ...attribute.KeyValue) {}
+func (nopLogger) TraceID() string                                  { return "" }
+func (nopLogger) RequestID() string                                { return "" }
+func (nopLogger) SpanID() string                                   { return "" }
+
+func (nopLogger) Inject(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+func (n nopLogger) FromContext(context.Context, string) Logger           { return n }
+func (n nopLogger) FromContextOK(context.Context, string) (Logger, bool) { return n, false }
+func (n nopLogger) FromGin(*gin.Context, string) Logger                  { return n }
+func (nopLogger) InjectToGin(*gin.Context, Logger)                       {}
+func (nopLogger) RecoverPanic(*gin.Context) func()                       { return func() {} }
@@ -0,0 +1,35 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNopLogger(t *testing.T) {
+	logger := NewNop()
+
+	assert.NotPanics(t, func() {
+		child := logger.WithField("k", "v").WithError(errors.New("boom")).Child("child")
+		child.Info("info")
+		child.Errorf("error %d", 1)
+		child.Fatal("fatal")
+		child.Start("timer").Stop()
+	})
+	assert.Equal(t, context.Background(), logger.Ctx())
+
+	ran := false
+	logger.WithTracer("op", func(ctx context.Context) { ran = true })
+	assert.True(t, ran)
+}
+
+func TestNopLoggerDoesNotAllocate(t *testing.T) {
+	logger := NewNop()
+	allocs := testing.AllocsPerRun(100, func() {
+		logger.WithField("key", "value").Info("hot path")
+		logger.Start("op").Stop()
+	})
+	assert.Zero(t, allocs)
+}
@@ -0,0 +1,201 @@
+package eotel
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http/protobuf"
+)
+
+// otlpProtocol returns cfg.OtelProtocol, defaulting to grpc.
+func otlpProtocol(cfg Config) (string, error) {
+	switch cfg.OtelProtocol {
+	case "", otlpProtocolGRPC:
+		return otlpProtocolGRPC, nil
+	case otlpProtocolHTTP:
+		return otlpProtocolHTTP, nil
+	default:
+		return "", fmt.Errorf("unsupported OTLP protocol %q", cfg.OtelProtocol)
+	}
+}
+
+// isEndpointURL reports whether the collector endpoint is a URL (as in
+// OTEL_EXPORTER_OTLP_ENDPOINT) rather than a bare host:port.
+func isEndpointURL(endpoint string) bool {
+	return strings.Contains(endpoint, "://")
+}
+
+// otlpTLS describes how the exporters connect to the collector: in
+// plaintext, or over TLS with tlsConfig (nil RootCAs means system roots).
+type otlpTLS struct {
+	insecure  bool
+	tlsConfig *tls.Config
+}
+
+func newOTLPTLS(cfg Config) (otlpTLS, error) {
+	if otlpInsecure(cfg) {
+		return otlpTLS{insecure: true}, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.OtelTLSCACert != "" {
+		pem, err := os.ReadFile(cfg.OtelTLSCACert)
+		if err != nil {
+			return otlpTLS{}, fmt.Errorf("read CA cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return otlpTLS{}, fmt.Errorf("CA cert %s: no certificates found", cfg.OtelTLSCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return otlpTLS{tlsConfig: tlsConfig}, nil
+}
+
+// otlpInsecure reports whether to skip TLS: when OtelInsecure is set, for
+// http:// endpoint URLs, and by default for localhost endpoints.
+func otlpInsecure(cfg Config) bool {
+	if cfg.OtelInsecure {
+		return true
+	}
+	host := cfg.OtelCollector
+	if isEndpointURL(host) {
+		u, err := url.Parse(host)
+		if err != nil {
+			return false
+		}
+		if u.Scheme == "http" {
+			return true
+		}
+		if u.Scheme == "https" {
+			return false
+		}
+		host = u.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newTraceExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	protocol, err := otlpProtocol(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sec, err := newOTLPTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == otlpProtocolHTTP {
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.OtelHeaders)}
+		if isEndpointURL(cfg.OtelCollector) {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OtelCollector))
+		} else {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OtelCollector))
+		}
+		if sec.insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(sec.tlsConfig))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithHeaders(cfg.OtelHeaders),
+		otlptracegrpc.WithDialOption(grpc.WithBlock()),
+	}
+	if isEndpointURL(cfg.OtelCollector) {
+		opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.OtelCollector))
+	} else {
+		opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OtelCollector))
+	}
+	if sec.insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(sec.tlsConfig)))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+func newMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	protocol, err := otlpProtocol(cfg)
+	if err != nil {
+		return nil, err
+	}
+	sec, err := newOTLPTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == otlpProtocolHTTP {
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(cfg.OtelHeaders)}
+		if isEndpointURL(cfg.OtelCollector) {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(cfg.OtelCollector))
+		} else {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(cfg.OtelCollector))
+		}
+		if sec.insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(sec.tlsConfig))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithHeaders(cfg.OtelHeaders),
+		otlpmetricgrpc.WithDialOption(grpc.WithBlock()),
+	}
+	if isEndpointURL(cfg.OtelCollector) {
+		opts = append(opts, otlpmetricgrpc.WithEndpointURL(cfg.OtelCollector))
+	} else {
+		opts = append(opts, otlpmetricgrpc.WithEndpoint(cfg.OtelCollector))
+	}
+	if sec.insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	} else {
+		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(sec.tlsConfig)))
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+// parseOTLPHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format: comma
+// separated key=value pairs with URL-encoded values. Malformed pairs are
+// skipped.
+func parseOTLPHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[key] = strings.TrimSpace(value)
+	}
+	return headers
+}
@@ -0,0 +1,177 @@
+package eotel
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseOTLPHeaders(t *testing.T) {
+	headers := parseOTLPHeaders("Authorization=Bearer%20secret, x-tenant = acme,malformed,=empty")
+	assert.Equal(t, map[string]string{
+		"Authorization": "Bearer secret",
+		"x-tenant":      "acme",
+	}, headers)
+}
+
+func TestLoadConfigFromEnvOTLPVariables(t *testing.T) {
+	t.Setenv("OTEL_COLLECTOR", "legacy:4317")
+	assert.Equal(t, "legacy:4317", LoadConfigFromEnv().OtelCollector)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+
+	cfg := LoadConfigFromEnv()
+	assert.Equal(t, "http://collector:4318", cfg.OtelCollector)
+	assert.Equal(t, "http/protobuf", cfg.OtelProtocol)
+	assert.Equal(t, map[string]string{"api-key": "secret"}, cfg.OtelHeaders)
+}
+
+func exportTestSpan(t *testing.T, cfg Config) {
+	exp, err := newTraceExporter(context.Background(), cfg)
+	require.NoError(t, err)
+	defer exp.Shutdown(context.Background())
+
+	spans := tracetest.SpanStubs{{Name: "op"}}.Snapshots()
+	require.NoError(t, exp.ExportSpans(context.Background(), spans))
+}
+
+func TestTraceExporterHTTPProtobuf(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		path   string
+		apiKey string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path, apiKey = r.URL.Path, r.Header.Get("api-key")
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	exportTestSpan(t, Config{
+		OtelCollector: srv.URL,
+		OtelProtocol:  "http/protobuf",
+		OtelHeaders:   map[string]string{"api-key": "secret"},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "secret", apiKey)
+}
+
+type fakeTraceCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	mu     sync.Mutex
+	apiKey []string
+}
+
+func (c *fakeTraceCollector) Export(ctx context.Context, _ *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.mu.Lock()
+	c.apiKey = md.Get("api-key")
+	c.mu.Unlock()
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestTraceExporterGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	collector := &fakeTraceCollector{}
+	srv := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(srv, collector)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	exportTestSpan(t, Config{
+		OtelCollector: lis.Addr().String(),
+		OtelHeaders:   map[string]string{"api-key": "secret"},
+	})
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	assert.Equal(t, []string{"secret"}, collector.apiKey)
+}
+
+func TestOTLPTLSModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		insecure bool
+	}{
+		{"localhost defaults to insecure", Config{OtelCollector: "localhost:4317"}, true},
+		{"loopback ip defaults to insecure", Config{OtelCollector: "127.0.0.1:4317"}, true},
+		{"remote host defaults to tls", Config{OtelCollector: "otel-collector:4317"}, false},
+		{"http url is insecure", Config{OtelCollector: "http://otel-collector:4318"}, true},
+		{"https url uses tls", Config{OtelCollector: "https://localhost:4318"}, false},
+		{"explicit insecure", Config{OtelCollector: "otel-collector:4317", OtelInsecure: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sec, err := newOTLPTLS(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.insecure, sec.insecure)
+			if tt.insecure {
+				assert.Nil(t, sec.tlsConfig)
+			} else {
+				require.NotNil(t, sec.tlsConfig)
+				assert.Nil(t, sec.tlsConfig.RootCAs)
+			}
+		})
+	}
+}
+
+func writeCACert(t *testing.T, srv *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, pemBytes, 0o600))
+	return path
+}
+
+func TestOTLPTLSCustomCA(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		hits int
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := Config{OtelCollector: srv.URL, OtelProtocol: "http/protobuf", OtelTLSCACert: writeCACert(t, srv)}
+	sec, err := newOTLPTLS(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, sec.tlsConfig.RootCAs)
+
+	exportTestSpan(t, cfg)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, hits)
+}
+
+func TestOTLPTLSBadCACert(t *testing.T) {
+	_, err := newOTLPTLS(Config{OtelCollector: "otel-collector:4317", OtelTLSCACert: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "read CA cert")
+
+	_, err = newOTLPTLS(Config{OtelCollector: "otel-collector:4317", OtelTLSCACert: writeConfigFile(t, "ca.pem", "not a cert")})
+	assert.ErrorContains(t, err, "no certificates found")
+}
@@ -0,0 +1,56 @@
+package eotel
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+const (
+	metricsExporterOTLP       = "otlp"
+	metricsExporterPrometheus = "prometheus"
+)
+
+// promRegistry holds the metrics served by MetricsHandler when InitEOTEL
+// installed the Prometheus exporter.
+var promRegistry *prometheus.Registry
+
+// metricsExporter returns cfg.MetricsExporter, defaulting to otlp.
+func metricsExporter(cfg Config) (string, error) {
+	switch cfg.MetricsExporter {
+	case "", metricsExporterOTLP:
+		return metricsExporterOTLP, nil
+	case metricsExporterPrometheus:
+		return metricsExporterPrometheus, nil
+	default:
+		return "", fmt.Errorf("unsupported metrics exporter %q", cfg.MetricsExporter)
+	}
+}
+
+// newPrometheusReader returns a reader that collects metrics when reg is
+// scraped. reg is private to eotel so re-initialising does not collide with
+// collectors registered by the application.
+func newPrometheusReader(reg *prometheus.Registry) (sdkmetric.Reader, error) {
+	return otelprom.New(otelprom.WithRegisterer(reg))
+}
+
+// MetricsHandler serves the metrics recorded through eotel in the Prometheus
+// text format, for mounting at /metrics. It answers 404 unless InitEOTEL ran
+// with EnableMetrics and MetricsExporter "prometheus"; it may be mounted
+// before InitEOTEL is called.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		initMu.Lock()
+		reg := promRegistry
+		initMu.Unlock()
+		if reg == nil {
+			http.Error(w, "eotel: prometheus metrics are not enabled", http.StatusNotFound)
+			return
+		}
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package eotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+)
+
+func TestMetricsHandlerServesPrometheusMetrics(t *testing.T) {
+	t.Cleanup(func() { otel.SetMeterProvider(metricnoop.NewMeterProvider()) })
+	initTestEOTEL(t, Config{ServiceName: "test-service", EnableMetrics: true, MetricsExporter: "prometheus"})
+
+	logger := New(context.Background(), "TestLogger")
+	logger.Info("scraped")
+	logger.End()
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "log_total{")
+	assert.Contains(t, body, "log_duration_ms_bucket{")
+}
+
+func TestMetricsHandlerWithoutPrometheus(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
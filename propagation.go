@@ -0,0 +1,72 @@
+package eotel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newPropagator builds a composite propagator from a comma separated list of
+// names: tracecontext, baggage and b3. An empty list means
+// "tracecontext,baggage".
+func newPropagator(names string) (propagation.TextMapPropagator, error) {
+	if strings.TrimSpace(names) == "" {
+		names = "tracecontext,baggage"
+	}
+	var props []propagation.TextMapPropagator
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "tracecontext":
+			props = append(props, propagation.TraceContext{})
+		case "baggage":
+			props = append(props, propagation.Baggage{})
+		case "b3":
+			props = append(props, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader|b3.B3SingleHeader)))
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown propagator %q", name)
+		}
+	}
+	return propagation.NewCompositeTextMapPropagator(props...), nil
+}
+
+// SpanContextHeaders encodes sc as W3C traceparent/tracestate headers, for
+// storing alongside a queued message so its consumer can link back to it.
+func SpanContextHeaders(sc trace.SpanContext) map[string]string {
+	headers := map[string]string{}
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	propagation.TraceContext{}.Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+// LinkFromHeaders decodes headers written by SpanContextHeaders into a link
+// for StartLinked. It reports false when they hold no valid span context.
+func LinkFromHeaders(headers map[string]string) (trace.Link, bool) {
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier(headers))
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return trace.Link{}, false
+	}
+	return trace.Link{SpanContext: sc}, true
+}
+
+// MapCarrier adapts a map[string]string, such as Kafka or RabbitMQ message
+// headers, for InjectTraceContext and ExtractTraceContext.
+type MapCarrier = propagation.MapCarrier
+
+// InjectTraceContext writes the trace context and baggage of ctx into
+// carrier using the propagators configured by InitEOTEL.
+func InjectTraceContext(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// ExtractTraceContext returns ctx carrying the trace context and baggage
+// found in carrier, so spans started from it continue the sender's trace.
+func ExtractTraceContext(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
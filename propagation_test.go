@@ -0,0 +1,101 @@
+package eotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewPropagatorFields(t *testing.T) {
+	prop, err := newPropagator("")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, prop.Fields())
+
+	prop, err = newPropagator("tracecontext, b3")
+	require.NoError(t, err)
+	assert.Contains(t, prop.Fields(), "traceparent")
+	assert.Contains(t, prop.Fields(), "x-b3-traceid")
+
+	_, err = newPropagator("tracecontext,jaeger")
+	assert.Error(t, err)
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	sr := newSpanRecorder(t)
+	prop, err := newPropagator("tracecontext,baggage")
+	require.NoError(t, err)
+	setPropagator(t, prop)
+
+	r := gin.New()
+	r.Use(Middleware("test"))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	require.NotEmpty(t, spans)
+	root := spans[len(spans)-1]
+	assert.Equal(t, "GET /ping", root.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", root.Parent().SpanID().String())
+	assert.True(t, root.Parent().IsRemote())
+}
+
+func TestInitEOTELInstallsPropagator(t *testing.T) {
+	initTestEOTEL(t, Config{ServiceName: "test-service", Propagators: "tracecontext"})
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate"}, otel.GetTextMapPropagator().Fields())
+
+	require.NoError(t, Shutdown(context.Background()))
+	_, err := InitEOTEL(context.Background(), Config{ServiceName: "test-service", Propagators: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestStartLinkedRecordsLinks(t *testing.T) {
+	sr := newSpanRecorder(t)
+	producer, _ := newObservedLogger("producer")
+	enqueue := producer.ChildKind("jobs enqueue", trace.SpanKindProducer)
+	headers := SpanContextHeaders(enqueue.(*Eotel).span.SpanContext())
+	enqueue.End()
+	require.Contains(t, headers, "traceparent")
+
+	link, ok := LinkFromHeaders(headers)
+	require.True(t, ok)
+	worker, _ := newObservedLogger("worker")
+	worker.StartLinked("jobs process", link).Info("processed")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	process := spans[1]
+	assert.Equal(t, "jobs process", process.Name())
+	require.Len(t, process.Links(), 1)
+	assert.True(t, process.Links()[0].SpanContext.Equal(spans[0].SpanContext().WithRemote(true)))
+
+	_, ok = LinkFromHeaders(map[string]string{})
+	assert.False(t, ok)
+}
+
+func TestTraceContextRoundTripsThroughHeaders(t *testing.T) {
+	newSpanRecorder(t)
+	initTestEOTEL(t, Config{ServiceName: "test-service"})
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "publish")
+	defer span.End()
+	headers := map[string]string{}
+	InjectTraceContext(ctx, MapCarrier(headers))
+	require.Contains(t, headers, "traceparent")
+
+	extracted := ExtractTraceContext(context.Background(), MapCarrier(headers))
+	sc := trace.SpanContextFromContext(extracted)
+	assert.True(t, sc.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), sc.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), sc.SpanID())
+}
@@ -0,0 +1,30 @@
+package eotel
+
+import "strings"
+
+const redacted = "***"
+
+// redact returns the value to log for a field: "***" when key matches one of
+// RedactKeys (case-insensitively), otherwise whatever the Redactor hook
+// substitutes, otherwise value unchanged.
+func redact(key string, value any) any {
+	if redactedKey(key) {
+		return redacted
+	}
+	if globalCfg.Redactor != nil {
+		if v, ok := globalCfg.Redactor(key, value); ok {
+			return v
+		}
+	}
+	return value
+}
+
+// redactedKey reports whether key matches one of RedactKeys.
+func redactedKey(key string) bool {
+	for _, k := range globalCfg.RedactKeys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
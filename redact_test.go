@@ -0,0 +1,83 @@
+package eotel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setRedaction(t *testing.T, keys []string, redactor func(string, any) (any, bool)) {
+	prevKeys, prevRedactor := globalCfg.RedactKeys, globalCfg.Redactor
+	globalCfg.RedactKeys, globalCfg.Redactor = keys, redactor
+	t.Cleanup(func() { globalCfg.RedactKeys, globalCfg.Redactor = prevKeys, prevRedactor })
+}
+
+func TestRedactedFieldsNeverReachExporters(t *testing.T) {
+	setRedaction(t, []string{"password", "Authorization"}, func(key string, value any) (any, bool) {
+		if s, ok := value.(string); ok && strings.HasPrefix(s, "sk_") {
+			return "sk_***", true
+		}
+		return nil, false
+	})
+	sr := newSpanRecorder(t)
+	l, logs := newObservedLogger("redact")
+
+	l.WithField("PASSWORD", "hunter2").
+		WithFields(map[string]any{"authorization": "Bearer abc", "api_key": "sk_live_123", "user": "bob"}).
+		Info("login")
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "***", fields["PASSWORD"])
+	assert.Equal(t, "***", fields["authorization"])
+	assert.Equal(t, "sk_***", fields["api_key"])
+	assert.Equal(t, "bob", fields["user"])
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	for _, kv := range spans[0].Attributes() {
+		v := kv.Value.Emit()
+		assert.NotContains(t, v, "hunter2", string(kv.Key))
+		assert.NotContains(t, v, "Bearer abc", string(kv.Key))
+		assert.NotContains(t, v, "sk_live_123", string(kv.Key))
+	}
+}
+
+func TestSetSpanAttrIsRedacted(t *testing.T) {
+	setRedaction(t, []string{"token"}, nil)
+	sr := newSpanRecorder(t)
+	l := New(t.Context(), "redact").Child("work")
+
+	l.SetSpanAttr("token", "secret")
+	l.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	var token string
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "token" {
+			token = kv.Value.AsString()
+		}
+	}
+	assert.Equal(t, "***", token)
+}
+
+func TestTypedFieldsAreRedacted(t *testing.T) {
+	setRedaction(t, []string{"pin"}, nil)
+	l, logs := newObservedLogger("redact")
+
+	l.WithInt("pin", 1234).WithStr("user", "bob").Info("login")
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "***", fields["pin"])
+	assert.Equal(t, "bob", fields["user"])
+
+	setRedaction(t, nil, func(key string, value any) (any, bool) {
+		return "masked", key == "user"
+	})
+	l.WithStr("user", "bob").Info("login")
+	assert.Equal(t, "masked", logs.All()[1].ContextMap()["user"])
+}
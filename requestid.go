@@ -0,0 +1,77 @@
+package eotel
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultRequestIDHeader = "X-Request-Id"
+
+// RequestIDHeader returns the header request IDs are read from and echoed
+// in, Config.RequestIDHeader or X-Request-Id.
+func RequestIDHeader() string {
+	if globalCfg.RequestIDHeader != "" {
+		return globalCfg.RequestIDHeader
+	}
+	return defaultRequestIDHeader
+}
+
+// NewRequestID returns a random 32-character hex request ID.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithRequestID returns a copy of the logger tagged with the request ID id:
+// it is logged as the request_id field and Loki label, and RequestID
+// returns it.
+func (l *Eotel) WithRequestID(id string) Logger {
+	c := l.WithField("request_id", id).WithLabel("request_id", id).(*Eotel)
+	c.requestID = id
+	return c
+}
+
+// RequestID returns the ID set with WithRequestID, e.g. by the middleware
+// from the incoming request, or "" when there is none.
+func (l *Eotel) RequestID() string {
+	return l.requestID
+}
+
+// maxRequestIDLen bounds the request IDs accepted from callers; longer
+// ones would bloat every log line and can make Loki reject a whole batch
+// over one over-long request_id label.
+const maxRequestIDLen = 128
+
+// ResolveRequestID returns the request ID the caller sent, read with get
+// from RequestIDHeader, or a new one when it is missing, longer than 128
+// bytes or not printable ASCII. It sets the ID on span and echoes it back
+// with set. The middlewares use it, and so can middleware for other
+// frameworks.
+func ResolveRequestID(span trace.Span, get func(key string) string, set func(key, value string)) string {
+	header := RequestIDHeader()
+	id := get(header)
+	if !validRequestID(id) {
+		id = NewRequestID()
+	}
+	span.SetAttributes(attribute.String("request_id", id))
+	set(header, id)
+	return id
+}
+
+// validRequestID reports whether a caller-supplied request ID is fit to
+// log and use as a Loki label.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package eotel
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestRequestIDIsLoggedAndLabelled(t *testing.T) {
+	logger, logs := newObservedLogger("test")
+	l := logger.WithRequestID("req-123").(*Eotel)
+	l.Info("hello")
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "req-123", logs.All()[0].ContextMap()["request_id"])
+	assert.Equal(t, "req-123", l.labels["request_id"])
+	assert.Equal(t, "req-123", l.RequestID())
+	assert.Empty(t, logger.RequestID())
+}
+
+func TestRequestIDHeaderFollowsConfig(t *testing.T) {
+	prev := globalCfg.RequestIDHeader
+	t.Cleanup(func() { globalCfg.RequestIDHeader = prev })
+
+	globalCfg.RequestIDHeader = ""
+	assert.Equal(t, "X-Request-Id", RequestIDHeader())
+	globalCfg.RequestIDHeader = "X-Correlation-Id"
+	assert.Equal(t, "X-Correlation-Id", RequestIDHeader())
+}
+
+func TestResolveRequestIDRejectsUnfitIDs(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	for _, tc := range []struct {
+		name, sent string
+		kept       bool
+	}{
+		{"valid", "req-123", true},
+		{"longest", strings.Repeat("a", 128), true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"control byte", "req\x00123", false},
+		{"non-ascii", "req-ü", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, resp := http.Header{}, http.Header{}
+			req.Set("X-Request-Id", tc.sent)
+			_, span := Tracer().Start(t.Context(), "request")
+			id := ResolveRequestID(span, req.Get, resp.Set)
+			span.End()
+
+			if tc.kept {
+				assert.Equal(t, tc.sent, id)
+			} else {
+				assert.NotEqual(t, tc.sent, id)
+				assert.Len(t, id, 32)
+			}
+			assert.Equal(t, id, resp.Get("X-Request-Id"))
+			spans := sr.Ended()
+			assert.Contains(t, spans[len(spans)-1].Attributes(), attribute.String("request_id", id))
+		})
+	}
+}
@@ -1,15 +1,31 @@
 package eotel
 
 import (
-	"github.com/getsentry/sentry-go"
+	"sync"
 	"time"
+
+	"github.com/getsentry/sentry-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxBreadcrumbs caps the trail kept per logger; older entries are dropped.
+const maxBreadcrumbs = 100
+
 func CaptureError(err error, tags map[string]string, extras map[string]interface{}) {
+	captureError(err, tags, extras, nil)
+}
+
+func captureError(err error, tags map[string]string, extras map[string]any, crumbs []*sentry.Breadcrumb) {
 	if err == nil || !globalCfg.EnableSentry {
 		return
 	}
 	sentry.WithScope(func(scope *sentry.Scope) {
+		if pc, ok := sentryPropagation(tags); ok {
+			scope.SetPropagationContext(pc)
+		}
+		for _, b := range crumbs {
+			scope.AddBreadcrumb(b, maxBreadcrumbs)
+		}
 		for k, v := range tags {
 			scope.SetTag(k, v)
 		}
@@ -20,3 +36,111 @@ func CaptureError(err error, tags map[string]string, extras map[string]interface
 	})
 	sentry.Flush(2 * time.Second)
 }
+
+// CaptureMessage sends msg to Sentry as an event at the given eotel level.
+func CaptureMessage(level, msg string, tags map[string]string) {
+	captureMessage(level, msg, tags, nil)
+}
+
+func captureMessage(level, msg string, tags map[string]string, crumbs []*sentry.Breadcrumb) {
+	if msg == "" || !globalCfg.EnableSentry {
+		return
+	}
+	sentry.WithScope(func(scope *sentry.Scope) {
+		if pc, ok := sentryPropagation(tags); ok {
+			scope.SetPropagationContext(pc)
+		}
+		for _, b := range crumbs {
+			scope.AddBreadcrumb(b, maxBreadcrumbs)
+		}
+		for k, v := range tags {
+			scope.SetTag(k, v)
+		}
+		scope.SetLevel(sentryLevel(level))
+		sentry.CaptureMessage(msg)
+	})
+	sentry.Flush(2 * time.Second)
+}
+
+// sentryTags identifies the service and, when sc is valid, the span a log
+// line was written on, so a Sentry issue can be traced back to the trace.
+func sentryTags(sc trace.SpanContext) map[string]string {
+	tags := map[string]string{
+		"service": globalCfg.ServiceName,
+		"job":     globalCfg.JobName,
+	}
+	if sc.IsValid() {
+		tags["trace_id"] = sc.TraceID().String()
+		tags["span_id"] = sc.SpanID().String()
+	}
+	return tags
+}
+
+// sentryPropagation builds the Sentry trace context from the trace_id and
+// span_id tags, which links the event to the OTEL trace in Sentry.
+func sentryPropagation(tags map[string]string) (sentry.PropagationContext, bool) {
+	traceID, err := trace.TraceIDFromHex(tags["trace_id"])
+	if err != nil {
+		return sentry.PropagationContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(tags["span_id"])
+	if err != nil {
+		return sentry.PropagationContext{}, false
+	}
+	return sentry.PropagationContext{
+		TraceID: sentry.TraceID(traceID),
+		SpanID:  sentry.SpanID(spanID),
+	}, true
+}
+
+// breadcrumbs is the trail of log lines written through a logger and the
+// loggers derived from it, sent along with the Sentry events they capture.
+// Each logger made by New (one per request in the middlewares) starts its
+// own trail.
+type breadcrumbs struct {
+	mu    sync.Mutex
+	items []*sentry.Breadcrumb
+}
+
+func (b *breadcrumbs) add(level, msg string) {
+	if b == nil || !globalCfg.EnableSentry {
+		return
+	}
+	crumb := &sentry.Breadcrumb{
+		Type:      "default",
+		Category:  "log",
+		Message:   msg,
+		Level:     sentryLevel(level),
+		Timestamp: time.Now(),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.items) == maxBreadcrumbs {
+		b.items = append(b.items[:0], b.items[1:]...)
+	}
+	b.items = append(b.items, crumb)
+}
+
+func (b *breadcrumbs) list() []*sentry.Breadcrumb {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*sentry.Breadcrumb(nil), b.items...)
+}
+
+func sentryLevel(level string) sentry.Level {
+	switch level {
+	case "debug":
+		return sentry.LevelDebug
+	case "warn":
+		return sentry.LevelWarning
+	case "error":
+		return sentry.LevelError
+	case "fatal":
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelInfo
+	}
+}
@@ -0,0 +1,155 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+type sentryTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *sentryTransport) Configure(sentry.ClientOptions) {}
+func (t *sentryTransport) Flush(time.Duration) bool       { return true }
+func (t *sentryTransport) FlushWithContext(context.Context) bool {
+	return true
+}
+func (t *sentryTransport) Close() {}
+
+func (t *sentryTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *sentryTransport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// newSentryTransport points the global Sentry hub at an in-memory transport
+// and enables Sentry in globalCfg for the duration of the test.
+func newSentryTransport(t *testing.T) *sentryTransport {
+	transport := &sentryTransport{}
+	require.NoError(t, sentry.Init(sentry.ClientOptions{
+		Dsn:       "https://public@sentry.example.com/1",
+		Transport: transport,
+	}))
+	prev := globalCfg.EnableSentry
+	globalCfg.EnableSentry = true
+	t.Cleanup(func() {
+		globalCfg.EnableSentry = prev
+		_ = sentry.Init(sentry.ClientOptions{})
+	})
+	return transport
+}
+
+func TestCapturedErrorCarriesBreadcrumbs(t *testing.T) {
+	transport := newSentryTransport(t)
+	req, _ := newObservedLogger("request")
+	other, _ := newObservedLogger("other-request")
+
+	req.Info("loading order")
+	other.Info("unrelated request")
+	req.WithField("order_id", 7).Warn("order is stale")
+	req.WithError(errors.New("payment declined")).Error("checkout failed")
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	crumbs := events[0].Breadcrumbs
+	require.Len(t, crumbs, 2)
+	assert.Equal(t, "log", crumbs[0].Category)
+	assert.Equal(t, "loading order", crumbs[0].Message)
+	assert.Equal(t, sentry.LevelInfo, crumbs[0].Level)
+	assert.Equal(t, "order is stale", crumbs[1].Message)
+	assert.Equal(t, sentry.LevelWarning, crumbs[1].Level)
+}
+
+func TestCapturedErrorCarriesTraceContext(t *testing.T) {
+	transport := newSentryTransport(t)
+	newSpanRecorder(t)
+	logger, _ := newObservedLogger("checkout")
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+	logger.WithError(errors.New("payment declined")).ErrorCtx(ctx, "checkout failed")
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	sc := span.SpanContext()
+	assert.Equal(t, sc.TraceID().String(), events[0].Tags["trace_id"])
+	assert.Equal(t, sc.SpanID().String(), events[0].Tags["span_id"])
+	assert.Equal(t, globalCfg.ServiceName, events[0].Tags["service"])
+	assert.Equal(t, sc.TraceID().String(), fmt.Sprint(events[0].Contexts["trace"]["trace_id"]))
+	assert.Equal(t, sc.SpanID().String(), fmt.Sprint(events[0].Contexts["trace"]["span_id"]))
+}
+
+func TestCapturedErrorCarriesFieldsAsExtras(t *testing.T) {
+	transport := newSentryTransport(t)
+	setRedaction(t, []string{"password"}, nil)
+	logger, _ := newObservedLogger("checkout")
+
+	logger.WithField("user_id", 7).
+		WithField("password", "hunter2").
+		WithError(errors.New("payment declined")).
+		ErrorAttrs("checkout failed", map[string]any{"attempt": 2})
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	extra := events[0].Extra
+	assert.Equal(t, int64(7), extra["user_id"])
+	assert.Equal(t, int64(2), extra["attempt"])
+	assert.Equal(t, "***", extra["password"])
+	assert.Equal(t, "payment declined", extra["error"])
+}
+
+func TestInitPassesSentryOptions(t *testing.T) {
+	t.Cleanup(func() { _ = sentry.Init(sentry.ClientOptions{}) })
+	initTestEOTEL(t, Config{
+		ServiceName:       "test-service",
+		EnableSentry:      true,
+		SentryDSN:         "https://public@sentry.example.com/1",
+		SentryEnvironment: "staging",
+		SentryRelease:     "orders@1.4.2",
+		SentrySampleRate:  0.25,
+	})
+
+	client := sentry.CurrentHub().Client()
+	require.NotNil(t, client)
+	opts := client.Options()
+	assert.Equal(t, "staging", opts.Environment)
+	assert.Equal(t, "orders@1.4.2", opts.Release)
+	assert.Equal(t, 0.25, opts.SampleRate)
+}
+
+func TestSentryEnvironmentFromEnv(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "staging")
+	assert.Equal(t, "staging", LoadConfigFromEnv().SentryEnvironment)
+	assert.Equal(t, "production", sentryOptions(Config{}).Environment)
+}
+
+func TestCaptureMessageMapsLevel(t *testing.T) {
+	transport := newSentryTransport(t)
+	logger, _ := newObservedLogger("quota")
+
+	logger.Info("checking quota")
+	logger.CaptureMessage("warn", "quota almost exhausted")
+
+	events := transport.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "quota almost exhausted", events[0].Message)
+	assert.Equal(t, sentry.LevelWarning, events[0].Level)
+	assert.Equal(t, globalCfg.ServiceName, events[0].Tags["service"])
+	require.Len(t, events[0].Breadcrumbs, 1)
+}
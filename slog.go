@@ -0,0 +1,100 @@
+package eotel
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewSlogHandler returns a slog.Handler that writes records through an eotel
+// logger named name, so code using log/slog still gets trace enrichment,
+// Loki and Sentry. Attributes become fields (groups are flattened to
+// "group.key") and an error-valued attribute is attached with WithError.
+func NewSlogHandler(ctx context.Context, name string) slog.Handler {
+	return &slogHandler{logger: New(ctx, name)}
+}
+
+type slogHandler struct {
+	logger Logger
+	prefix string
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return levelEnabled(slogLevel(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	logger := h.logger
+	r.Attrs(func(a slog.Attr) bool {
+		logger = withSlogAttr(logger, h.prefix, a)
+		return true
+	})
+	// slog passes context.Background() from its non-Context methods; keep the
+	// logger's own context then so its span and baggage still apply.
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = logger.Ctx()
+	}
+
+	switch slogLevel(r.Level) {
+	case "debug":
+		logger.DebugCtx(ctx, r.Message)
+	case "info":
+		logger.InfoCtx(ctx, r.Message)
+	case "warn":
+		logger.WarnCtx(ctx, r.Message)
+	default:
+		logger.ErrorCtx(ctx, r.Message)
+	}
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	logger := h.logger
+	for _, a := range attrs {
+		logger = withSlogAttr(logger, h.prefix, a)
+	}
+	return &slogHandler{logger: logger, prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+func withSlogAttr(logger Logger, prefix string, a slog.Attr) Logger {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return logger
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			logger = withSlogAttr(logger, prefix, ga)
+		}
+		return logger
+	}
+	if err, ok := a.Value.Any().(error); ok {
+		return logger.WithError(err)
+	}
+	return logger.WithField(prefix+a.Key, a.Value.Any())
+}
+
+// slogLevel maps a slog level to the nearest eotel level. Levels above
+// error map to error, never fatal.
+func slogLevel(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
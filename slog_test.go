@@ -0,0 +1,52 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSlogHandlerAddsTraceContext(t *testing.T) {
+	newSpanRecorder(t)
+	base, logs := newObservedLogger("slog")
+	logger := slog.New(&slogHandler{logger: base})
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+	logger.With("service_area", "orders").
+		WithGroup("order").
+		InfoContext(ctx, "created", "id", 42, slog.Group("customer", "tier", "gold"))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "created", entries[0].Message)
+	assert.Equal(t, span.SpanContext().TraceID().String(), fields["trace_id"])
+	assert.Equal(t, "info", fields["level"])
+	assert.Equal(t, "orders", fields["service_area"])
+	assert.Equal(t, int64(42), fields["order.id"])
+	assert.Equal(t, "gold", fields["order.customer.tier"])
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	base, logs := newObservedLogger("slog")
+	logger := slog.New(&slogHandler{logger: base})
+
+	logger.Debug("hidden")
+	logger.Warn("careful")
+	logger.Error("failed", "err", errors.New("boom"))
+	logger.Log(context.Background(), slog.LevelError+4, "critical")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "warn", entries[0].ContextMap()["level"])
+	assert.Equal(t, "error", entries[1].ContextMap()["level"])
+	assert.Equal(t, "boom", entries[1].ContextMap()["error"])
+	assert.Equal(t, "error", entries[2].ContextMap()["level"])
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelDebug))
+}
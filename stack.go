@@ -0,0 +1,160 @@
+package eotel
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// callers returns the program counters of the calling goroutine's stack,
+// skipping skip frames above the caller of callers.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+// panicError is a recovered panic value together with the stack of the
+// goroutine that panicked. Its StackTrace method follows github.com/pkg/errors,
+// so both the stacktrace log field and Sentry pick the stack up.
+type panicError struct {
+	value any
+	pcs   []uintptr
+}
+
+// NewPanicError returns an error for the panic value rec that carries the
+// stack from the panicking function down. Call it in the deferred function
+// that recovered, while that stack is still there:
+//
+//	if rec := recover(); rec != nil {
+//		logger.WithError(eotel.NewPanicError(rec)).Error("unhandled panic")
+//	}
+func NewPanicError(rec any) error {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	// Frames above runtime.gopanic are the recovery code, not the panic.
+	for i, pc := range pcs {
+		if fn := runtime.FuncForPC(pc - 1); fn != nil && fn.Name() == "runtime.gopanic" {
+			pcs = pcs[i+1:]
+			break
+		}
+	}
+	return &panicError{value: rec, pcs: pcs}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// Unwrap returns the panic value when it is an error, e.g. for errors.Is.
+func (e *panicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
+func (e *panicError) StackTrace() []uintptr {
+	return e.pcs
+}
+
+// stackPolicy is a logger's choice of whether its errors carry a stack trace,
+// set with WithStack and WithoutStack.
+type stackPolicy uint8
+
+const (
+	stackDefault stackPolicy = iota // follow Config.CaptureStack
+	stackAlways
+	stackNever
+)
+
+// capturesStack reports whether errors added to l record a stack trace.
+func (l *Eotel) capturesStack() bool {
+	switch l.stack {
+	case stackAlways:
+		return true
+	case stackNever:
+		return false
+	}
+	return globalCfg.CaptureStack
+}
+
+// shouldCaptureStack reports whether a log line at level carries the stack
+// trace of its attached error.
+func (l *Eotel) shouldCaptureStack(level string) bool {
+	return l.capturesStack() && parseLevel(level) >= zapcore.ErrorLevel
+}
+
+// WithStack returns a copy of the logger whose error lines carry the stack
+// trace of their error whatever Config.CaptureStack says, e.g. on a critical
+// path. An error already attached gets the stack of this call.
+func (l *Eotel) WithStack() Logger {
+	c := l.clone()
+	c.stack = stackAlways
+	if c.err != nil && c.errStack == nil {
+		c.errStack = callers(1)
+	}
+	return c
+}
+
+// WithoutStack returns a copy of the logger that never attaches a stack
+// trace, sparing the capture for cheap, expected errors.
+func (l *Eotel) WithoutStack() Logger {
+	c := l.clone()
+	c.stack = stackNever
+	c.errStack = nil
+	return c
+}
+
+// errorStack formats the stack trace recorded by github.com/pkg/errors deepest
+// in err's chain, falling back to pcs when there is none.
+func errorStack(err error, pcs []uintptr) string {
+	if st := pkgErrorsStack(err); st != nil {
+		pcs = st
+	}
+	if len(pcs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		b.WriteString(f.Function)
+		b.WriteString("\n\t")
+		b.WriteString(f.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(f.Line))
+		if !more {
+			break
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// pkgErrorsStack finds a StackTrace() method returning a slice of program
+// counters (as github.com/pkg/errors does) without depending on that package.
+func pkgErrorsStack(err error) []uintptr {
+	var pcs []uintptr
+	for ; err != nil; err = errors.Unwrap(err) {
+		m := reflect.ValueOf(err).MethodByName("StackTrace")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		out := m.Type().Out(0)
+		if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+			continue
+		}
+		st := m.Call(nil)[0]
+		frames := make([]uintptr, st.Len())
+		for i := range frames {
+			frames[i] = uintptr(st.Index(i).Uint())
+		}
+		pcs = frames
+	}
+	return pcs
+}
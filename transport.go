@@ -0,0 +1,92 @@
+package eotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transport traces each request it sends through base.
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) so every outbound
+// request gets a client span, carries the trace context and baggage in its
+// headers, and records its status and latency in http_client_duration_ms.
+// Failed requests and 5xx responses are logged at warn level, under the
+// client span, through the logger in the request's context.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	// Start client span
+	ctx, span := Tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", req.URL.Redacted()),
+		),
+	)
+	defer span.End()
+
+	// Inject outgoing trace context and baggage; a RoundTripper must not
+	// modify the caller's request, so send a copy
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	durationMs := float64(time.Since(start)) / float64(time.Millisecond)
+	span.SetAttributes(attribute.Float64("http.client.duration_ms", durationMs))
+
+	class := "error"
+	switch {
+	case err != nil:
+		failSpan(span, err)
+		// failSpan has recorded err on the span already; WithError would
+		// record it a second time.
+		clientLogger(ctx, req).WithField("error", err.Error()).Warn("http request failed")
+	default:
+		class = fmt.Sprintf("%dxx", resp.StatusCode/100)
+		span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			clientLogger(ctx, req).
+				WithField("http.response.status_code", resp.StatusCode).
+				Warn("http request returned " + resp.Status)
+		}
+	}
+
+	hist, herr := otel.Meter(globalCfg.ServiceName).Float64Histogram("http_client_duration_ms")
+	if herr == nil {
+		hist.Record(ctx, durationMs, metric.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.status_class", class),
+		))
+	}
+	return resp, err
+}
+
+// clientLogger returns the logger in ctx bound to the client span, so a
+// failed call is logged on that span rather than failing the request being
+// served. Its keys differ from the server middleware's so they do not
+// overwrite the served request's method and path.
+func clientLogger(ctx context.Context, req *http.Request) Logger {
+	return FromContext(ctx, "http.client").WithContext(ctx).
+		WithField("http.request.method", req.Method).
+		WithField("url.full", req.URL.Redacted())
+}
@@ -0,0 +1,110 @@
+package eotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTransportTracesOutboundRequest(t *testing.T) {
+	sr := newSpanRecorder(t)
+	reader := newMetricReader(t)
+	setPropagator(t, propagation.TraceContext{})
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+"/brew", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Empty(t, req.Header.Get("traceparent"), "caller's request must not be modified")
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "HTTP GET", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Contains(t, span.Attributes(), attribute.Int("http.status_code", http.StatusTeapot))
+	assert.Equal(t, codes.Unset, span.Status().Code)
+
+	require.NotEmpty(t, traceparent)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+	assert.Contains(t, traceparent, span.SpanContext().SpanID().String())
+	assert.Equal(t, uint64(1), histogramCount(t, reader, "http_client_duration_ms"))
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransportMarksFailedRequest(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	client := &http.Client{Transport: NewTransport(failingTransport{})}
+	_, err := client.Get("http://example.invalid/")
+	require.Error(t, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "connection refused", spans[0].Status().Description)
+}
+
+func TestTransportLogsFailureOnClientSpan(t *testing.T) {
+	sr := newSpanRecorder(t)
+
+	ctx, server := Tracer().Start(context.Background(), "GET /orders")
+	logger, logs := newObservedLogger("orders")
+	ctx = Inject(ctx, logger.WithContext(ctx).WithField("method", http.MethodGet))
+
+	client := &http.Client{Transport: NewTransport(failingTransport{})}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.invalid/pay", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.Error(t, err)
+	server.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	clientSpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, codes.Error, clientSpan.Status().Code)
+	assert.Contains(t, clientSpan.Attributes(), attribute.String("http.request.method", http.MethodPost))
+	var exceptions int
+	for _, ev := range clientSpan.Events() {
+		if ev.Name == "exception" {
+			exceptions++
+		}
+	}
+	assert.Equal(t, 1, exceptions, "each failure is recorded once")
+	assert.Equal(t, codes.Unset, serverSpan.Status().Code, "the served request must not fail")
+	assert.Empty(t, serverSpan.Events())
+	assert.NotContains(t, serverSpan.Attributes(), attribute.String("method", http.MethodPost))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, http.MethodGet, fields["method"])
+	assert.Equal(t, http.MethodPost, fields["http.request.method"])
+	assert.Equal(t, "http://example.invalid/pay", fields["url.full"])
+	assert.Equal(t, "connection refused", fields["error"])
+}